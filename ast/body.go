@@ -1,7 +1,42 @@
 package ast
 
+import "strings"
+
 type FunctionDefinition struct {
 	Name string
-	Args []string
+	Signature
 	Body Expression
 }
+
+// the parameters of a function, e.g. (a (b 10) ..rest)
+type Signature struct {
+	Args []string
+	// the default values of optional arguments, nil for required ones
+	Defaults []Expression
+	// collects the remaining positional arguments, empty without ..rest
+	Rest string
+}
+
+// Default returns the default value of the i-th argument, or nil if it's required
+func (s Signature) Default(i int) Expression {
+	if i < len(s.Defaults) {
+		return s.Defaults[i]
+	}
+	return nil
+}
+
+// Format shows the signature of a function called name, e.g. (f a [b] ..rest)
+// optional arguments are in brackets
+func (s Signature) Format(name string) string {
+	parts := []string{name}
+	for i, arg := range s.Args {
+		if s.Default(i) != nil {
+			arg = "[" + arg + "]"
+		}
+		parts = append(parts, arg)
+	}
+	if s.Rest != "" {
+		parts = append(parts, ".."+s.Rest)
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
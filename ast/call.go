@@ -12,3 +12,32 @@ type NamedCall struct {
 	Function  string
 	Arguments []Expression
 }
+
+// `git commit -m ${msg}` runs a command, every argument is a string or an interpolated string
+type Command struct {
+	Arguments []Expression
+}
+
+// (pipe "input" `grep a` (sh "wc" "-l")) connects the output of every command to the input of the next.
+// The first stage may be a value, which is written to the input of the first command.
+type Pipe struct {
+	Stages []Expression
+}
+
+// (> "file" cmd), (>> "file" cmd) or (< "file" cmd)
+type Redirect struct {
+	Op      string
+	File    Expression
+	Command Expression
+}
+
+// IsCommand reports whether expr runs a process, these can be used in pipes and redirections
+func IsCommand(expr Expression) bool {
+	switch expr := expr.(type) {
+	case Command, Pipe, Redirect:
+		return true
+	case NamedCall:
+		return expr.Function == "sh"
+	}
+	return false
+}
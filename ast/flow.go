@@ -17,3 +17,48 @@ type OrFlow struct {
 type AndFlow struct {
 	Arguments []Expression
 }
+
+// (while cond body...)
+type WhileLoop struct {
+	Condition Expression
+	Body      Expression
+}
+
+// (for (x in coll) body...), x can be any pattern allowed in let
+type ForLoop struct {
+	Pattern    Expression
+	Collection Expression
+	Body       Expression
+}
+
+// (loop (i 0 acc 1) body...), (recur ...) starts the body again with new values for the bindings
+type Loop struct {
+	Bindings []Binding
+	Body     Expression
+}
+
+// (recur (+ i 1) (* acc i))
+type Recur struct {
+	Arguments []Expression
+}
+
+// (break) or (break value), Value is nil without a value
+type Break struct {
+	Value Expression
+}
+
+// (continue)
+type Continue struct{}
+
+// (try body... (catch e handler...)), Name is bound to the error in Handler
+type Try struct {
+	Body    Expression
+	Name    string
+	Handler Expression
+}
+
+// (with-env {"X" 1} body...) sets environment variables while the body runs
+type WithEnv struct {
+	Vars Expression
+	Body Expression
+}
@@ -1,5 +1,10 @@
 package ast
 
+import (
+	"math/big"
+	"regexp"
+)
+
 type IdentLiteral struct {
 	Value string
 }
@@ -14,21 +19,53 @@ type IntLiteral struct {
 	Value int64
 }
 
+// an integer literal too large for an int64
+type BigIntLiteral struct {
+	Value *big.Int
+}
+
+// 1/3
+type RationalLiteral struct {
+	Value *big.Rat
+}
+
 type FloatLiteral struct {
 	Value float64
 }
 
-// TODO might want to do this more complex, include string interpolation
 type StringLiteral struct {
 	Value string
 }
 
+// "hello ${name}"
+// Parts are StringLiterals and the expressions inside of ${...}
+type InterpolatedString struct {
+	Parts []Expression
+}
+
+// #/[a-z]+/
+// compiled while parsing, so that invalid expressions are reported early
+type RegexLiteral struct {
+	Regexp *regexp.Regexp
+}
+
 type ArrayLiteral struct {
 	Values []Expression
 }
 
-// (fun [x y z] (+ x (+ y z)))
+// {"a" 1 "b" 2}
+type MapLiteral struct {
+	Keys   []Expression
+	Values []Expression
+}
+
+// (fun (x y z) (+ x (+ y z)))
 type LambdaLiteral struct {
-	Arguments []string
-	Body      Expression
+	Signature
+	Body Expression
+}
+
+// :name, as a keyword argument in a call, e.g. (f 1 :b 2)
+type KeywordLiteral struct {
+	Name string
 }
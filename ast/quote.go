@@ -0,0 +1,20 @@
+package ast
+
+// '(a b) or (quote (a b)), and with Quasi ~(a ,b ,@c) or (quasiquote (a ,b ,@c))
+// Datum is the quoted code as a value.Object, where ,b and ,@c are value.Unquote
+type Quote struct {
+	Datum interface{}
+	Quasi bool
+}
+
+// a value.Object inserted into the code by a macro, e.g. the value of ,x
+type Constant struct {
+	Value interface{}
+}
+
+// (macro name (args) body...)
+type MacroDefinition struct {
+	Name string
+	Signature
+	Body Expression
+}
@@ -6,3 +6,60 @@ type VariableDefiniton struct {
 	Value Expression
 	Body  Expression
 }
+
+// (let (a 1 [x y] arr) body)
+// bindings are evaluated in order, each one can use the ones before
+type Let struct {
+	Bindings []Binding
+	Body     Expression
+}
+
+type Binding struct {
+	Pattern Expression
+	Value   Expression
+}
+
+// Patterns on the left side of a let binding.
+
+// x, or _ to ignore the value
+type IdentPattern struct {
+	Ident string
+}
+
+// [x y ..rest], Rest is empty without ..rest
+type ArrayPattern struct {
+	Elements []Expression
+	Rest     string
+}
+
+// (Point x y) with a pattern for every field, in the order of the class definition
+type ClassPattern struct {
+	Class  string
+	Fields []Expression
+}
+
+// (def x 1), an immutable global
+type GlobalDefinition struct {
+	Ident string
+	Value Expression
+}
+
+// (var x 1), a mutable variable until the end of the enclosing do block
+type VarDeclaration struct {
+	Ident string
+	Value Expression
+}
+
+// (set! x 2)
+type Assignment struct {
+	Ident string
+	Value Expression
+}
+
+// (import "lib/math.lisp" as m) binds the module to Alias.
+// (import (only foo bar) from "util") binds the members in Only instead.
+type Import struct {
+	Path  string
+	Alias string
+	Only  []string
+}
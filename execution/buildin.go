@@ -0,0 +1,145 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"interpreter/value"
+)
+
+// Buildin populates env with the functions and values every script can rely on.
+// This includes the prelude from the standard library, unless it was disabled with DisablePrelude.
+func Buildin(env *Env) {
+	builtins(env)
+
+	if !env.modules.noPrelude {
+		prelude(env)
+	}
+}
+
+// builtins are the functions implemented in go
+func builtins(env *Env) {
+	env.DefineGlobal("true", value.NewBool(true))
+	env.DefineGlobal("false", value.NewBool(false))
+	env.DefineGlobal("nil", value.Nil())
+
+	env.DefineGlobal("print", value.NewNativeFunction(func(o []value.Object) (value.Object, error) {
+		for _, obj := range o {
+			fmt.Print(obj.Str())
+		}
+		fmt.Println()
+
+		return value.Nil(), nil
+	}))
+
+	env.DefineGlobal("str", value.NewNativeFunction(func(o []value.Object) (value.Object, error) {
+		s := ""
+		for _, obj := range o {
+			s += obj.Str()
+		}
+		return value.NewString(s), nil
+	}))
+
+	// the arguments after the script on the command line
+	env.DefineGlobal("args", stringArray(env.modules.args))
+
+	// (script-path) is the absolute path of the script, nil for code from -e or stdin
+	env.DefineGlobal("script-path", &envFunction{func(env *Env, args []value.Object) (value.Object, error) {
+		if err := expectArgs("script-path", args, 0); err != nil {
+			return nil, err
+		}
+
+		if env.modules.script == "" {
+			return value.Nil(), nil
+		}
+		return value.NewString(env.modules.script), nil
+	}})
+
+	// (exit) or (exit code) ends the program, try doesn't catch it
+	env.DefineGlobal("exit", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgsBetween("exit", args, 0, 1); err != nil {
+			return nil, err
+		}
+
+		code := int64(0)
+		if len(args) == 1 {
+			var err error
+			if code, err = asInt("exit", args[0]); err != nil {
+				return nil, err
+			}
+		}
+		// the os only keeps the lowest 8 bits
+		if code < 0 || code > 255 {
+			return nil, errors.New(fmt.Sprint("exit code ", code, " is not between 0 and 255"))
+		}
+		return nil, exitSignal{int(code)}
+	}))
+
+	// (throw message) fails with message, catch binds it as the value of the error
+	env.DefineGlobal("throw", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("throw", args, 1); err != nil {
+			return nil, err
+		}
+		return nil, thrownError{message: args[0].Str(), value: args[0]}
+	}))
+
+	// (type 1) is "Int", the class name for instances of user defined classes
+	env.DefineGlobal("type", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("type", args, 1); err != nil {
+			return nil, err
+		}
+		return value.NewString(args[0].Class()), nil
+	}))
+
+	buildinArith(env)
+	buildinNumber(env)
+	buildinCompare(env)
+	buildinMap(env)
+	buildinArray(env)
+	buildinTyped(env)
+	buildinString(env)
+	buildinRegex(env)
+	buildinChan(env)
+	buildinSymbol(env)
+	buildinMacro(env)
+	buildinShell(env)
+	buildinEnv(env)
+	buildinFs(env)
+	buildinIter(env)
+}
+
+// envFunction is a builtin, that needs the environment it is called from, e.g. to read its variables
+type envFunction struct {
+	fn func(env *Env, args []value.Object) (value.Object, error)
+}
+
+func (f *envFunction) Str() string {
+	return ":native code:"
+}
+
+func (f *envFunction) Class() string {
+	return "Native Function"
+}
+
+func (f *envFunction) Boolean() bool {
+	return true
+}
+
+func expectArgs(name string, args []value.Object, n int) error {
+	if len(args) != n {
+		return errors.New(fmt.Sprint(name, " expects ", n, " arguments, got ", len(args)))
+	}
+
+	return nil
+}
+
+func expectArgsBetween(name string, args []value.Object, min, max int) error {
+	if len(args) < min || len(args) > max {
+		return errors.New(fmt.Sprint(name, " expects ", min, " to ", max, " arguments, got ", len(args)))
+	}
+
+	return nil
+}
+
+func typeError(name string, expected string, got value.Object) error {
+	return errors.New(name + " expected " + expected + ", got " + got.Class())
+}
@@ -0,0 +1,57 @@
+package execution
+
+import (
+	"interpreter/value"
+)
+
+func buildinArith(env *Env) {
+	env.DefineGlobal("+", fold("+", value.Add, value.NewInt(0)))
+	env.DefineGlobal("*", fold("*", value.Mul, value.NewInt(1)))
+
+	// (- x) negates x
+	env.DefineGlobal("-", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if len(args) == 1 {
+			return value.Sub(value.NewInt(0), args[0])
+		}
+		return fold("-", value.Sub, nil).Call(args)
+	}))
+
+	env.DefineGlobal("/", fold("/", value.Div, nil))
+
+	env.DefineGlobal("%", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("%", args, 2); err != nil {
+			return nil, err
+		}
+		return value.Mod(args[0], args[1])
+	}))
+
+	env.DefineGlobal("pow", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("pow", args, 2); err != nil {
+			return nil, err
+		}
+		return value.Pow(args[0], args[1])
+	}))
+}
+
+// fold applies op from left to right, e.g. (+ 1 2 3) is (+ (+ 1 2) 3)
+// identity is returned when there are no arguments. Without identity, at least one argument is required.
+func fold(name string, op func(a, b value.Object) (value.Object, error), identity value.Object) *value.NativeFunction {
+	return value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if len(args) == 0 {
+			if identity == nil {
+				return nil, expectArgs(name, args, 2)
+			}
+			return identity, nil
+		}
+
+		acc := args[0]
+		for _, arg := range args[1:] {
+			var err error
+			acc, err = op(acc, arg)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return acc, nil
+	})
+}
@@ -0,0 +1,360 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"interpreter/value"
+	"sort"
+)
+
+func buildinArray(env *Env) {
+	env.DefineGlobal("len", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("len", args, 1); err != nil {
+			return nil, err
+		}
+
+		switch coll := args[0].(type) {
+		case value.Sequence:
+			return value.NewInt(int64(coll.Len())), nil
+		case *value.Map:
+			return value.NewInt(int64(coll.Len())), nil
+		case *value.StringClass:
+			return value.NewInt(int64(coll.Len())), nil
+		}
+
+		return nil, typeError("len", "Array, Map or String", args[0])
+	}))
+
+	// (slice arr from) or (slice arr from to)
+	env.DefineGlobal("slice", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgsBetween("slice", args, 2, 3); err != nil {
+			return nil, err
+		}
+
+		arr, err := asSequence("slice", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		from, err := asInt("slice", args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		to := int64(arr.Len())
+		if len(args) == 3 {
+			to, err = asInt("slice", args[2])
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if from < 0 || to > int64(arr.Len()) || from > to {
+			return nil, errors.New(fmt.Sprint("slice [", from, " ", to, "] out of range for array of length ", arr.Len()))
+		}
+
+		return arr.Pick(positions(int(from), int(to))), nil
+	}))
+
+	// (push arr value ...) appends in place and returns the array
+	env.DefineGlobal("push", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if len(args) == 0 {
+			return nil, errors.New("push expects an array followed by values")
+		}
+
+		switch arr := args[0].(type) {
+		case *value.Array:
+			arr.Push(args[1:]...)
+			return arr, nil
+		// typed arrays only accept fitting elements
+		case interface {
+			Push(...value.Object) error
+		}:
+			if err := arr.Push(args[1:]...); err != nil {
+				return nil, err
+			}
+			return args[0], nil
+		}
+
+		return nil, typeError("push", "Array", args[0])
+	}))
+
+	env.DefineGlobal("concat", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		seqs := make([]value.Sequence, 0, len(args))
+		for _, arg := range args {
+			seq, err := asSequence("concat", arg)
+			if err != nil {
+				return nil, err
+			}
+
+			seqs = append(seqs, seq)
+		}
+
+		return value.Concat(seqs...), nil
+	}))
+
+	// (map arr f), lazy for iterators
+	env.DefineGlobal("map", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("map", args, 2); err != nil {
+			return nil, err
+		}
+
+		if it, ok := args[0].(*value.Iterator); ok {
+			return mapIterator(env, it, args[1]), nil
+		}
+
+		arr, err := asSequence("map", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		values := make([]value.Object, 0, arr.Len())
+		for i := 0; i < arr.Len(); i++ {
+			v, err := call(env, args[1], []value.Object{arr.At(i)})
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, v)
+		}
+
+		return value.NewArrayOf(values), nil
+	}))
+
+	// (filter arr f), lazy for iterators
+	env.DefineGlobal("filter", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("filter", args, 2); err != nil {
+			return nil, err
+		}
+
+		if it, ok := args[0].(*value.Iterator); ok {
+			return filterIterator(env, it, args[1]), nil
+		}
+
+		arr, err := asSequence("filter", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		kept := make([]int, 0)
+		for i := 0; i < arr.Len(); i++ {
+			keep, err := call(env, args[1], []value.Object{arr.At(i)})
+			if err != nil {
+				return nil, err
+			}
+
+			if keep.Boolean() {
+				kept = append(kept, i)
+			}
+		}
+
+		return arr.Pick(kept), nil
+	}))
+
+	// (reduce arr f) or (reduce arr f init)
+	// without init, the first element is used
+	env.DefineGlobal("reduce", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgsBetween("reduce", args, 2, 3); err != nil {
+			return nil, err
+		}
+
+		// iterators are reduced while they are pulled
+		if _, ok := args[0].(*value.Iterator); !ok {
+			if _, err := asSequence("reduce", args[0]); err != nil {
+				return nil, err
+			}
+		}
+
+		var acc value.Object
+		if len(args) == 3 {
+			acc = args[2]
+		}
+
+		err := each(args[0], func(v value.Object) (bool, error) {
+			if acc == nil {
+				acc = v
+				return true, nil
+			}
+
+			var err error
+			acc, err = call(env, args[1], []value.Object{acc, v})
+			return err == nil, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if acc == nil {
+			return nil, errors.New("reduce of empty array without initial value")
+		}
+		return acc, nil
+	}))
+
+	// (range to), (range from to) or (range from to step)
+	// the elements are all allocated, see maxRangeLength
+	env.DefineGlobal("range", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		r, err := rangeOf("range", args)
+		if err != nil {
+			return nil, err
+		}
+		if r.Len() > maxRangeLength {
+			return nil, errors.New(fmt.Sprint("range of ", r.Len(), " elements is too long, use lazy-range to compute them on access"))
+		}
+
+		values := make([]value.Object, 0, r.Len())
+		for i := 0; i < r.Len(); i++ {
+			values = append(values, r.At(i))
+		}
+
+		return value.NewArrayOf(values), nil
+	}))
+
+	// (lazy-range to), (lazy-range from to) or (lazy-range from to step)
+	// the elements are only computed on access
+	env.DefineGlobal("lazy-range", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		return rangeOf("lazy-range", args)
+	}))
+
+	env.DefineGlobal("reverse", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("reverse", args, 1); err != nil {
+			return nil, err
+		}
+
+		arr, err := asSequence("reverse", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		reversed := make([]int, 0, arr.Len())
+		for i := arr.Len() - 1; i >= 0; i-- {
+			reversed = append(reversed, i)
+		}
+
+		return arr.Pick(reversed), nil
+	}))
+
+	// (sort arr) or (sort arr compare)
+	// compare works like the compare builtin, returning a negative Int, 0 or a positive Int
+	env.DefineGlobal("sort", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgsBetween("sort", args, 1, 2); err != nil {
+			return nil, err
+		}
+
+		arr, err := asSequence("sort", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		compare := value.Compare
+		if len(args) == 2 {
+			compare = func(a, b value.Object) (int, error) {
+				res, err := call(env, args[1], []value.Object{a, b})
+				if err != nil {
+					return 0, err
+				}
+
+				i, err := asInt("sort comparator", res)
+				return int(i), err
+			}
+		}
+
+		// positions are sorted, so that typed arrays keep their type
+		// sort.SliceStable can't be interrupted, remember the first error instead
+		order := positions(0, arr.Len())
+		var sortErr error
+		sort.SliceStable(order, func(i, j int) bool {
+			if sortErr != nil {
+				return false
+			}
+
+			c, err := compare(arr.At(order[i]), arr.At(order[j]))
+			if err != nil {
+				sortErr = err
+			}
+			return c < 0
+		})
+
+		if sortErr != nil {
+			return nil, sortErr
+		}
+		return arr.Pick(order), nil
+	}))
+
+	env.DefineGlobal("contains?", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("contains?", args, 2); err != nil {
+			return nil, err
+		}
+
+		arr, err := asSequence("contains?", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		for i := 0; i < arr.Len(); i++ {
+			if value.Equal(arr.At(i), args[1]) {
+				return value.NewBool(true), nil
+			}
+		}
+		return value.NewBool(false), nil
+	}))
+}
+
+// asSequence accepts Arrays and typed arrays
+func asSequence(name string, o value.Object) (value.Sequence, error) {
+	if s, ok := o.(value.Sequence); ok {
+		return s, nil
+	}
+
+	return nil, typeError(name, "Array", o)
+}
+
+// positions returns from, from+1, ..., to-1
+func positions(from int, to int) []int {
+	ps := make([]int, 0, to-from)
+	for i := from; i < to; i++ {
+		ps = append(ps, i)
+	}
+	return ps
+}
+
+func asInt(name string, o value.Object) (int64, error) {
+	if i, ok := o.(*value.IntClass); ok {
+		return i.Value(), nil
+	}
+
+	return 0, typeError(name, "Int", o)
+}
+
+// maxRangeLength bounds the arrays built by range, so that a typo doesn't exhaust the memory.
+// lazy-range has no such limit.
+const maxRangeLength = 1 << 26
+
+// rangeOf reads the arguments of range and lazy-range
+func rangeOf(name string, args []value.Object) (*value.Range, error) {
+	if err := expectArgsBetween(name, args, 1, 3); err != nil {
+		return nil, err
+	}
+
+	bounds := make([]int64, 0, 3)
+	for _, arg := range args {
+		i, err := asInt(name, arg)
+		if err != nil {
+			return nil, err
+		}
+		bounds = append(bounds, i)
+	}
+
+	from, to, step := int64(0), bounds[0], int64(1)
+	if len(bounds) > 1 {
+		from, to = bounds[0], bounds[1]
+	}
+	if len(bounds) > 2 {
+		step = bounds[2]
+	}
+
+	if step == 0 {
+		return nil, errors.New(name + " with a step of 0")
+	}
+
+	return value.NewRange(from, to, step)
+}
@@ -0,0 +1,88 @@
+package execution
+
+import (
+	"errors"
+	"interpreter/value"
+)
+
+func buildinChan(env *Env) {
+	// (chan) or (chan capacity)
+	// send fails on a full channel, so without a capacity, nothing can be sent
+	env.DefineGlobal("chan", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgsBetween("chan", args, 0, 1); err != nil {
+			return nil, err
+		}
+
+		capacity := int64(0)
+		if len(args) == 1 {
+			var err error
+			capacity, err = asInt("chan", args[0])
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return value.NewChannel(int(capacity)), nil
+	}))
+
+	// (send ch value ...) returns ch
+	env.DefineGlobal("send", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if len(args) == 0 {
+			return nil, errors.New("send expects a channel followed by values")
+		}
+
+		ch, err := asChannel("send", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range args[1:] {
+			if err := ch.Send(v); err != nil {
+				return nil, err
+			}
+		}
+		return ch, nil
+	}))
+
+	// (recv ch) is nil, once the channel is closed and empty
+	env.DefineGlobal("recv", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("recv", args, 1); err != nil {
+			return nil, err
+		}
+
+		ch, err := asChannel("recv", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		v, ok, err := ch.Receive()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return v, nil
+		}
+		return value.Nil(), nil
+	}))
+
+	env.DefineGlobal("close", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("close", args, 1); err != nil {
+			return nil, err
+		}
+
+		ch, err := asChannel("close", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return value.Nil(), ch.Close()
+	}))
+}
+
+func asChannel(name string, o value.Object) (*value.Channel, error) {
+	if ch, ok := o.(*value.Channel); ok {
+		return ch, nil
+	}
+
+	return nil, typeError(name, "Channel", o)
+}
@@ -0,0 +1,100 @@
+package execution
+
+import (
+	"interpreter/value"
+)
+
+func buildinCompare(env *Env) {
+	env.DefineGlobal("==", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if len(args) < 2 {
+			return nil, expectArgs("==", args, 2)
+		}
+
+		for i := 1; i < len(args); i++ {
+			if !value.Equal(args[i-1], args[i]) {
+				return value.NewBool(false), nil
+			}
+		}
+		return value.NewBool(true), nil
+	}))
+
+	env.DefineGlobal("!=", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("!=", args, 2); err != nil {
+			return nil, err
+		}
+
+		return value.NewBool(!value.Equal(args[0], args[1])), nil
+	}))
+
+	env.DefineGlobal("<", ordering("<", func(c int) bool { return c < 0 }))
+	env.DefineGlobal("<=", ordering("<=", func(c int) bool { return c <= 0 }))
+	env.DefineGlobal(">", ordering(">", func(c int) bool { return c > 0 }))
+	env.DefineGlobal(">=", ordering(">=", func(c int) bool { return c >= 0 }))
+
+	env.DefineGlobal("compare", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("compare", args, 2); err != nil {
+			return nil, err
+		}
+
+		c, err := value.Compare(args[0], args[1])
+		if err != nil {
+			return nil, err
+		}
+		return value.NewInt(int64(c)), nil
+	}))
+
+	env.DefineGlobal("hash", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("hash", args, 1); err != nil {
+			return nil, err
+		}
+
+		h, err := value.Hash(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return value.NewInt(int64(h)), nil
+	}))
+}
+
+// ordering creates a comparison builtin, which holds if every neighbouring pair satisfies ok.
+// e.g. (< 1 2 3)
+// Arrays are compared element-wise, resulting in an array of Bools.
+// e.g. (> [1 2 3] 1) is [false true true]
+func ordering(name string, ok func(c int) bool) *value.NativeFunction {
+	return value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if len(args) < 2 {
+			return nil, expectArgs(name, args, 2)
+		}
+
+		if isSequence(args[0]) || isSequence(args[1]) {
+			if err := expectArgs(name, args, 2); err != nil {
+				return nil, err
+			}
+
+			return value.Broadcast(args[0], args[1], func(a, b value.Object) (value.Object, error) {
+				c, err := value.Compare(a, b)
+				if err != nil {
+					return nil, err
+				}
+				return value.NewBool(ok(c)), nil
+			})
+		}
+
+		for i := 1; i < len(args); i++ {
+			c, err := value.Compare(args[i-1], args[i])
+			if err != nil {
+				return nil, err
+			}
+
+			if !ok(c) {
+				return value.NewBool(false), nil
+			}
+		}
+		return value.NewBool(true), nil
+	})
+}
+
+func isSequence(o value.Object) bool {
+	_, ok := o.(value.Sequence)
+	return ok
+}
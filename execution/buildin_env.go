@@ -0,0 +1,229 @@
+package execution
+
+import (
+	"encoding/json"
+	"errors"
+	"interpreter/ast"
+	"interpreter/value"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+func buildinEnv(env *Env) {
+	// (env-get "HOME") is the environment variable, or nil if it isn't set. (env-get "X" "default")
+	env.DefineGlobal("env-get", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgsBetween("env-get", args, 1, 2); err != nil {
+			return nil, err
+		}
+
+		name, err := asName("env-get", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if v, ok := os.LookupEnv(name); ok {
+			return value.NewString(v), nil
+		}
+		if len(args) == 2 {
+			return args[1], nil
+		}
+		return value.Nil(), nil
+	}))
+
+	// (env-set! "X" v) sets the environment variable for this process and every command it runs.
+	// nil unsets it.
+	env.DefineGlobal("env-set!", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("env-set!", args, 2); err != nil {
+			return nil, err
+		}
+
+		name, err := asName("env-set!", args[0])
+		if err != nil {
+			return nil, err
+		}
+		return value.Nil(), setEnv(name, args[1])
+	}))
+
+	// (export-vars! true) passes the variables of the script to the commands it runs, see ExportVariables
+	env.DefineGlobal("export-vars!", &envFunction{func(env *Env, args []value.Object) (value.Object, error) {
+		if err := expectArgs("export-vars!", args, 1); err != nil {
+			return nil, err
+		}
+
+		env.modules.exportVars = args[0].Boolean()
+		return value.Nil(), nil
+	}})
+}
+
+// ExportVariables passes the variables in scope to every command as environment variables.
+// Strings are passed as they are, numbers, booleans, symbols and keywords as they are printed,
+// arrays, maps and instances of classes as json. Functions, classes and modules are left out.
+// A - in the name of a variable becomes a _, e.g. my-dir is exported as my_dir.
+func (e *Env) ExportVariables() {
+	e.modules.exportVars = true
+}
+
+// evalWithEnv sets the environment variables while the body is evaluated, and restores them afterwards
+func evalWithEnv(env *Env, with ast.WithEnv) (value.Object, error) {
+	v, err := Eval(env, with.Vars)
+	if err != nil {
+		return nil, err
+	}
+
+	vars, ok := v.(*value.Map)
+	if !ok {
+		return nil, typeError("with-env", "Map", v)
+	}
+
+	values := vars.Values()
+	for i, k := range vars.Keys() {
+		name, err := asName("with-env", k)
+		if err != nil {
+			return nil, err
+		}
+
+		previous, wasSet := os.LookupEnv(name)
+		if err := setEnv(name, values[i]); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if wasSet {
+				os.Setenv(name, previous)
+			} else {
+				os.Unsetenv(name)
+			}
+		}()
+	}
+
+	return Eval(env, with.Body)
+}
+
+func setEnv(name string, v value.Object) error {
+	if _, ok := v.(*value.NilClass); ok {
+		return os.Unsetenv(name)
+	}
+
+	s, ok, err := envString(v)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("a " + v.Class() + " can't be an environment variable")
+	}
+	return os.Setenv(name, s)
+}
+
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// exportedVariables are the variables in env as NAME=value, locals win over globals
+func exportedVariables(env *Env) []string {
+	vars := make(map[string]string)
+	for _, bindings := range []map[string]*value.Binding{env.globals, env.locals} {
+		for ident, b := range bindings {
+			name := strings.ReplaceAll(ident, "-", "_")
+			if !envName.MatchString(name) || ident == "true" || ident == "false" {
+				continue
+			}
+
+			if s, ok, err := envString(b.Value); ok && err == nil {
+				vars[name] = s
+			}
+		}
+	}
+
+	exported := make([]string, 0, len(vars))
+	for name, s := range vars {
+		exported = append(exported, name+"="+s)
+	}
+	sort.Strings(exported)
+	return exported
+}
+
+// envString is the value of an environment variable for v.
+// It reports false for values that have none, e.g. functions.
+func envString(v value.Object) (string, bool, error) {
+	switch v := v.(type) {
+	case *value.StringClass:
+		return v.Value(), true, nil
+	case *value.IntClass, *value.FloatClass, *value.BigInt, *value.Rational, *value.Decimal,
+		*value.BoolClass, *value.Symbol, *value.Keyword:
+		return v.Str(), true, nil
+	case *value.Array, *value.Map, *value.Class, *value.IntArray, *value.FloatArray, *value.ByteArray:
+		j, err := toJSON(v)
+		if err != nil {
+			return "", false, err
+		}
+		data, err := json.Marshal(j)
+		return string(data), err == nil, err
+	}
+	return "", false, nil
+}
+
+// toJSON converts v to what encoding/json marshals the same way.
+// Rationals are strings, json has no fractions.
+func toJSON(v value.Object) (interface{}, error) {
+	switch v := v.(type) {
+	case *value.NilClass:
+		return nil, nil
+	case *value.BoolClass:
+		return v.Boolean(), nil
+	case *value.IntClass:
+		return v.Value(), nil
+	case *value.FloatClass:
+		return v.Value(), nil
+	case *value.BigInt, *value.Decimal:
+		return json.Number(v.Str()), nil
+	case *value.StringClass:
+		return v.Value(), nil
+	case *value.Rational, *value.Symbol, *value.Keyword:
+		return v.Str(), nil
+
+	case *value.Map:
+		m := make(map[string]interface{})
+		values := v.Values()
+		for i, k := range v.Keys() {
+			// {:a 1} is {"a": 1}
+			key := k.Str()
+			switch k := k.(type) {
+			case *value.StringClass:
+				key = k.Value()
+			case *value.Keyword:
+				key = k.Name()
+			}
+
+			j, err := toJSON(values[i])
+			if err != nil {
+				return nil, err
+			}
+			m[key] = j
+		}
+		return m, nil
+
+	case *value.Class:
+		m := make(map[string]interface{})
+		fields := v.Fields()
+		for i, name := range v.FieldNames() {
+			j, err := toJSON(fields[i])
+			if err != nil {
+				return nil, err
+			}
+			m[name] = j
+		}
+		return m, nil
+
+	case value.Sequence:
+		elements := make([]interface{}, v.Len())
+		for i := range elements {
+			j, err := toJSON(v.At(i))
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = j
+		}
+		return elements, nil
+	}
+
+	return nil, errors.New("a " + v.Class() + " can't be converted to json")
+}
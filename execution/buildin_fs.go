@@ -0,0 +1,426 @@
+package execution
+
+import (
+	"errors"
+	"interpreter/value"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// fileInfo is what stat returns, modified is in unix seconds
+var fileInfo = newClassInfo("FileInfo", "name", "size", "dir?", "mode", "modified")
+
+// Relative paths start at the current directory, which cd changes.
+// Failures are errors, which try can catch.
+func buildinFs(env *Env) {
+	// (read "file") is the content as a string
+	env.DefineGlobal("read", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		path, err := pathArg("read", args)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return value.NewString(string(data)), nil
+	}))
+
+	// (read-lines "file") is an array of the lines, without line endings
+	env.DefineGlobal("read-lines", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		path, err := pathArg("read-lines", args)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		lines := make([]value.Object, 0)
+		if len(data) > 0 {
+			for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+				lines = append(lines, value.NewString(strings.TrimSuffix(line, "\r")))
+			}
+		}
+		return value.NewArrayOf(lines), nil
+	}))
+
+	// (read-bytes "file") is a ByteArray
+	env.DefineGlobal("read-bytes", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		path, err := pathArg("read-bytes", args)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return value.NewByteArray(data), nil
+	}))
+
+	// (write "file" content) replaces the file, content is a String or a ByteArray
+	env.DefineGlobal("write", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		return value.Nil(), writeFile("write", args, os.O_TRUNC)
+	}))
+
+	// (append "file" content) adds to the end of the file
+	env.DefineGlobal("append", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		return value.Nil(), writeFile("append", args, os.O_APPEND)
+	}))
+
+	env.DefineGlobal("exists?", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		path, err := pathArg("exists?", args)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = os.Stat(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		return value.NewBool(err == nil), nil
+	}))
+
+	// (ls) or (ls "dir") is an array of the names in the directory, sorted
+	env.DefineGlobal("ls", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		dir := "."
+		if len(args) > 0 {
+			var err error
+			if dir, err = pathArg("ls", args); err != nil {
+				return nil, err
+			}
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		names := make([]string, len(entries))
+		for i, e := range entries {
+			names[i] = e.Name()
+		}
+		return stringArray(names), nil
+	}))
+
+	// (glob "src/**/*.go"), ** matches any number of directories
+	env.DefineGlobal("glob", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		pattern, err := pathArg("glob", args)
+		if err != nil {
+			return nil, err
+		}
+
+		matches, err := glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return stringArray(matches), nil
+	}))
+
+	env.DefineGlobal("stat", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		path, err := pathArg("stat", args)
+		if err != nil {
+			return nil, err
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		return fileInfo.MakeInstance([]value.Object{
+			value.NewString(info.Name()),
+			value.NewInt(info.Size()),
+			value.NewBool(info.IsDir()),
+			value.NewString(info.Mode().String()),
+			value.NewInt(info.ModTime().Unix()),
+		})
+	}))
+
+	// (mkdir "a/b/c") creates the parents as well, like mkdir -p
+	env.DefineGlobal("mkdir", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		path, err := pathArg("mkdir", args)
+		if err != nil {
+			return nil, err
+		}
+		return value.Nil(), os.MkdirAll(path, 0755)
+	}))
+
+	// (rm "file") removes a file or an empty directory, (rm "dir" :recursive true) everything in it
+	env.DefineGlobal("rm", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		recursive := false
+		if len(args) == 3 {
+			if kw, ok := args[1].(*value.Keyword); !ok || kw.Name() != "recursive" {
+				return nil, errors.New("rm has no option " + args[1].Str() + ", expected :recursive")
+			}
+			recursive = args[2].Boolean()
+			args = args[:1]
+		}
+
+		path, err := pathArg("rm", args)
+		if err != nil {
+			return nil, err
+		}
+		if recursive {
+			return value.Nil(), os.RemoveAll(path)
+		}
+		return value.Nil(), os.Remove(path)
+	}))
+
+	// (mv from to), into to if it is a directory
+	env.DefineGlobal("mv", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		from, to, err := fromTo("mv", args)
+		if err != nil {
+			return nil, err
+		}
+		return value.Nil(), os.Rename(from, to)
+	}))
+
+	// (cp from to) copies a file or a whole directory, into to if it is a directory
+	env.DefineGlobal("cp", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		from, to, err := fromTo("cp", args)
+		if err != nil {
+			return nil, err
+		}
+		return value.Nil(), copyPath(from, to)
+	}))
+
+	env.DefineGlobal("cwd", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("cwd", args, 0); err != nil {
+			return nil, err
+		}
+
+		dir, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		return value.NewString(dir), nil
+	}))
+
+	// (cd "dir") changes the current directory of the script and the commands it runs
+	env.DefineGlobal("cd", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		path, err := pathArg("cd", args)
+		if err != nil {
+			return nil, err
+		}
+		return value.Nil(), os.Chdir(path)
+	}))
+
+	// (tempdir) creates a new empty directory and returns its path
+	env.DefineGlobal("tempdir", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("tempdir", args, 0); err != nil {
+			return nil, err
+		}
+
+		dir, err := os.MkdirTemp("", "interpreter")
+		if err != nil {
+			return nil, err
+		}
+		return value.NewString(dir), nil
+	}))
+}
+
+// pathArg expects a single String
+func pathArg(name string, args []value.Object) (string, error) {
+	if err := expectArgs(name, args, 1); err != nil {
+		return "", err
+	}
+
+	path, err := asString(name, args[0])
+	if err != nil {
+		return "", err
+	}
+	return path.Value(), nil
+}
+
+// fromTo expects two paths, the second one is joined with the name of the first one, if it is a directory
+func fromTo(name string, args []value.Object) (string, string, error) {
+	paths, err := asStrings(name, args, 2)
+	if err != nil {
+		return "", "", err
+	}
+
+	from, to := paths[0], paths[1]
+	if info, err := os.Stat(to); err == nil && info.IsDir() {
+		to = filepath.Join(to, filepath.Base(from))
+	}
+	return from, to, nil
+}
+
+func writeFile(name string, args []value.Object, flag int) error {
+	if err := expectArgs(name, args, 2); err != nil {
+		return err
+	}
+
+	path, err := asString(name, args[0])
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	switch content := args[1].(type) {
+	case *value.StringClass:
+		data = []byte(content.Value())
+	case *value.ByteArray:
+		data = content.Bytes()
+	default:
+		return typeError(name, "String or ByteArray", content)
+	}
+
+	f, err := os.OpenFile(path.Value(), os.O_CREATE|os.O_WRONLY|flag, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func copyPath(from, to string) error {
+	// copying a directory into itself would never end, copying a file onto itself empties it
+	if inside, err := isInside(to, from); err != nil || inside {
+		if err == nil {
+			err = errors.New("can't copy " + from + " into itself, to " + to)
+		}
+		return err
+	}
+
+	return filepath.WalkDir(from, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(to, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+		return copyFile(path, target, info.Mode().Perm())
+	})
+}
+
+// isInside reports whether path is dir, or lies somewhere under it
+func isInside(path, dir string) (bool, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return false, err
+	}
+
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false, err
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)), nil
+}
+
+func copyFile(from, to string, perm fs.FileMode) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(to, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// glob is filepath.Glob, where a ** segment matches any number of directories
+func glob(pattern string) ([]string, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	if !strings.Contains(pattern, "**") {
+		matches, err := filepath.Glob(pattern)
+		if matches == nil {
+			matches = []string{}
+		}
+		return matches, err
+	}
+
+	// the directories without wildcards are the root to search from
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	i := 0
+	for i < len(segments) && !strings.ContainsAny(segments[i], "*?[\\") {
+		i++
+	}
+	root := strings.Join(segments[:i], "/")
+	switch {
+	case root == "" && i > 0:
+		root = "/"
+	case root == "":
+		root = "."
+	}
+
+	matches := []string{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		// directories, which can't be read, are left out
+		if err != nil {
+			if path == root && errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return nil
+		}
+		if matchSegments(segments[i:], strings.Split(filepath.ToSlash(rel), "/")) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+
+	sort.Strings(matches)
+	return matches, err
+}
+
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(parts) == 0 {
+		return false
+	}
+	ok, _ := filepath.Match(pattern[0], parts[0])
+	return ok && matchSegments(pattern[1:], parts[1:])
+}
@@ -0,0 +1,216 @@
+package execution
+
+import (
+	"bufio"
+	"errors"
+	"interpreter/value"
+	"io"
+	"os"
+	"strings"
+)
+
+// Iterators are lazy, map, filter, take and drop only pull the elements they need.
+// (take (filter (lines "huge.log") error?) 10) stops reading after the 10th error.
+func buildinIter(env *Env) {
+	env.DefineGlobal("stdin", value.NewReader("stdin", os.Stdin))
+
+	// (lines "file") or (lines stdin) is an iterator of the lines, without line endings
+	env.DefineGlobal("lines", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("lines", args, 1); err != nil {
+			return nil, err
+		}
+
+		switch src := args[0].(type) {
+		case *value.StringClass:
+			f, err := os.Open(src.Value())
+			if err != nil {
+				return nil, err
+			}
+			return linesOf(f, f), nil
+		case *value.Reader:
+			return linesOf(src.Reader(), nil), nil
+		}
+		return nil, typeError("lines", "String or Reader", args[0])
+	}))
+
+	// (iter coll) iterates over anything for can, e.g. to map lazily over a range
+	env.DefineGlobal("iter", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("iter", args, 1); err != nil {
+			return nil, err
+		}
+		return iterate(args[0])
+	}))
+
+	// (collect it) is an array of all elements
+	env.DefineGlobal("collect", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("collect", args, 1); err != nil {
+			return nil, err
+		}
+
+		values := make([]value.Object, 0)
+		err := each(args[0], func(v value.Object) (bool, error) {
+			values = append(values, v)
+			return true, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return value.NewArrayOf(values), nil
+	}))
+
+	// (take xs n) are the first n elements
+	env.DefineGlobal("take", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		n, err := countArg("take", args)
+		if err != nil {
+			return nil, err
+		}
+
+		// the rest of the iterator is closed, once n elements are taken
+		if it, ok := args[0].(*value.Iterator); ok {
+			taken := 0
+			return value.NewClosingIterator(func() (value.Object, bool, error) {
+				if taken >= n {
+					return nil, false, nil
+				}
+				taken++
+				return it.Next()
+			}, it.Close), nil
+		}
+
+		arr, err := asSequence("take", args[0])
+		if err != nil {
+			return nil, err
+		}
+		if n > arr.Len() {
+			n = arr.Len()
+		}
+		return arr.Pick(positions(0, n)), nil
+	}))
+
+	// (drop xs n) are the elements after the first n
+	env.DefineGlobal("drop", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		n, err := countArg("drop", args)
+		if err != nil {
+			return nil, err
+		}
+
+		if it, ok := args[0].(*value.Iterator); ok {
+			return value.NewClosingIterator(func() (value.Object, bool, error) {
+				for ; n > 0; n-- {
+					if _, ok, err := it.Next(); !ok || err != nil {
+						return nil, false, err
+					}
+				}
+				return it.Next()
+			}, it.Close), nil
+		}
+
+		arr, err := asSequence("drop", args[0])
+		if err != nil {
+			return nil, err
+		}
+		if n > arr.Len() {
+			n = arr.Len()
+		}
+		return arr.Pick(positions(n, arr.Len())), nil
+	}))
+}
+
+// countArg expects a collection and a count, which isn't negative
+func countArg(name string, args []value.Object) (int, error) {
+	if err := expectArgs(name, args, 2); err != nil {
+		return 0, err
+	}
+
+	n, err := asInt(name, args[1])
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, errors.New(name + " expects a count of at least 0")
+	}
+	return int(n), nil
+}
+
+// linesOf reads r line by line, closer is closed once the iterator is exhausted or closed
+func linesOf(r io.Reader, closer io.Closer) *value.Iterator {
+	reader := bufio.NewReader(r)
+	next := func() (value.Object, bool, error) {
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			if err == io.EOF {
+				err = nil
+			}
+			return nil, false, err
+		}
+		return value.NewString(strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")), true, nil
+	}
+
+	if closer == nil {
+		return value.NewIterator(next)
+	}
+	return value.NewClosingIterator(next, func() { closer.Close() })
+}
+
+// iterate returns an iterator over the elements of coll, just like for would see them
+func iterate(coll value.Object) (*value.Iterator, error) {
+	switch coll := coll.(type) {
+	case *value.Iterator:
+		return coll, nil
+
+	case value.Sequence:
+		i := 0
+		return value.NewIterator(func() (value.Object, bool, error) {
+			if i >= coll.Len() {
+				return nil, false, nil
+			}
+			i++
+			return coll.At(i - 1), true, nil
+		}), nil
+
+	case *value.Channel:
+		return value.NewIterator(coll.Receive), nil
+	}
+
+	// strings and maps are small enough to be collected first
+	values := make([]value.Object, 0)
+	err := each(coll, func(v value.Object) (bool, error) {
+		values = append(values, v)
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return iterate(value.NewArrayOf(values))
+}
+
+func mapIterator(env *Env, it *value.Iterator, f value.Object) *value.Iterator {
+	return value.NewClosingIterator(func() (value.Object, bool, error) {
+		v, ok, err := it.Next()
+		if !ok || err != nil {
+			return nil, false, err
+		}
+
+		v, err = call(env, f, []value.Object{v})
+		return v, err == nil, err
+	}, it.Close)
+}
+
+func filterIterator(env *Env, it *value.Iterator, f value.Object) *value.Iterator {
+	return value.NewClosingIterator(func() (value.Object, bool, error) {
+		for {
+			v, ok, err := it.Next()
+			if !ok || err != nil {
+				return nil, false, err
+			}
+
+			keep, err := call(env, f, []value.Object{v})
+			if err != nil {
+				return nil, false, err
+			}
+			if keep.Boolean() {
+				return v, true, nil
+			}
+		}
+	}, it.Close)
+}
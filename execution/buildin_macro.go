@@ -0,0 +1,37 @@
+package execution
+
+import "interpreter/value"
+
+func buildinMacro(env *Env) {
+	// (list 'f 1 2) builds code like '(f 1 2)
+	env.DefineGlobal("list", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		return value.NewList(args), nil
+	}))
+
+	// (gensym) or (gensym "tmp") returns a fresh symbol, for names in code built by macros
+	env.DefineGlobal("gensym", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgsBetween("gensym", args, 0, 1); err != nil {
+			return nil, err
+		}
+
+		prefix := "g"
+		if len(args) == 1 {
+			s, err := asString("gensym", args[0])
+			if err != nil {
+				return nil, err
+			}
+			prefix = s.Value()
+		}
+		return gensym(prefix), nil
+	}))
+
+	// (macroexpand '(unless c x)) shows the code a macro call is replaced with
+	env.DefineGlobal("macroexpand", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("macroexpand", args, 1); err != nil {
+			return nil, err
+		}
+
+		expanded, _, err := expand(env, args[0], 0)
+		return expanded, err
+	}))
+}
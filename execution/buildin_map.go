@@ -0,0 +1,163 @@
+package execution
+
+import (
+	"errors"
+	"interpreter/value"
+)
+
+func buildinMap(env *Env) {
+	// (get m key) or (get m key default)
+	// arrays are indexed by Int, an array of Bools or an array of Ints
+	env.DefineGlobal("get", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgsBetween("get", args, 2, 3); err != nil {
+			return nil, err
+		}
+
+		switch coll := args[0].(type) {
+		case *value.Map:
+			v, ok, err := coll.Get(args[1])
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				return v, nil
+			}
+		case value.Sequence:
+			// (get arr mask) or (get arr indices)
+			if index, ok := args[1].(value.Sequence); ok {
+				return value.Select(coll, index)
+			}
+
+			i, err := asInt("get", args[1])
+			if err != nil {
+				return nil, err
+			}
+			if i >= 0 && i < int64(coll.Len()) {
+				return coll.At(int(i)), nil
+			}
+		default:
+			return nil, typeError("get", "Map or Array", coll)
+		}
+
+		if len(args) == 3 {
+			return args[2], nil
+		}
+		return value.Nil(), nil
+	}))
+
+	// (assoc m key value ...)
+	env.DefineGlobal("assoc", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if len(args) == 0 || len(args)%2 != 1 {
+			return nil, errors.New("assoc expects a map followed by key value pairs")
+		}
+
+		m, err := asMap("assoc", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		m = m.Copy()
+		for i := 1; i < len(args); i += 2 {
+			if err := m.Set(args[i], args[i+1]); err != nil {
+				return nil, err
+			}
+		}
+
+		return m, nil
+	}))
+
+	// (dissoc m key ...)
+	env.DefineGlobal("dissoc", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if len(args) == 0 {
+			return nil, errors.New("dissoc expects a map followed by keys")
+		}
+
+		m, err := asMap("dissoc", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		m = m.Copy()
+		for _, key := range args[1:] {
+			if err := m.Delete(key); err != nil {
+				return nil, err
+			}
+		}
+
+		return m, nil
+	}))
+
+	env.DefineGlobal("keys", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("keys", args, 1); err != nil {
+			return nil, err
+		}
+
+		m, err := asMap("keys", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return value.NewArray(m.Keys()...), nil
+	}))
+
+	env.DefineGlobal("values", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("values", args, 1); err != nil {
+			return nil, err
+		}
+
+		m, err := asMap("values", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return value.NewArray(m.Values()...), nil
+	}))
+
+	env.DefineGlobal("has?", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("has?", args, 2); err != nil {
+			return nil, err
+		}
+
+		m, err := asMap("has?", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		ok, err := m.Has(args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		return value.NewBool(ok), nil
+	}))
+
+	// (merge a b ...) later maps win
+	env.DefineGlobal("merge", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		merged := value.NewMap()
+
+		for _, arg := range args {
+			m, err := asMap("merge", arg)
+			if err != nil {
+				return nil, err
+			}
+
+			keys := m.Keys()
+			values := m.Values()
+			for i := range keys {
+				if err := merged.Set(keys[i], values[i]); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		return merged, nil
+	}))
+}
+
+func asMap(name string, o value.Object) (*value.Map, error) {
+	if m, ok := o.(*value.Map); ok {
+		return m, nil
+	}
+
+	return nil, typeError(name, "Map", o)
+}
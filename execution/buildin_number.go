@@ -0,0 +1,154 @@
+package execution
+
+import (
+	"errors"
+	"interpreter/value"
+	"math/big"
+	"strconv"
+)
+
+// conversions between the numeric types
+func buildinNumber(env *Env) {
+	// (rational 1 3) is 1/3, (rational x) converts any number exactly
+	env.DefineGlobal("rational", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgsBetween("rational", args, 1, 2); err != nil {
+			return nil, err
+		}
+
+		x, err := asRat("rational", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if len(args) == 2 {
+			y, err := asRat("rational", args[1])
+			if err != nil {
+				return nil, err
+			}
+			if y.Sign() == 0 {
+				return nil, errors.New("rational with a denominator of 0")
+			}
+			x = new(big.Rat).Quo(x, y)
+		}
+
+		return value.NewRational(x), nil
+	}))
+
+	env.DefineGlobal("numerator", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("numerator", args, 1); err != nil {
+			return nil, err
+		}
+
+		r, err := asRat("numerator", args[0])
+		if err != nil {
+			return nil, err
+		}
+		return value.NewBigInt(r.Num()), nil
+	}))
+
+	env.DefineGlobal("denominator", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("denominator", args, 1); err != nil {
+			return nil, err
+		}
+
+		r, err := asRat("denominator", args[0])
+		if err != nil {
+			return nil, err
+		}
+		return value.NewBigInt(r.Denom()), nil
+	}))
+
+	// (decimal x), (decimal x scale) or (decimal x scale mode)
+	// x is a number or a String like "12.50". Without a scale, it's taken from x.
+	// mode is one of "half-even" (the default), "half-up", "half-down", "up", "down", "ceiling" or "floor"
+	env.DefineGlobal("decimal", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgsBetween("decimal", args, 1, 3); err != nil {
+			return nil, err
+		}
+
+		mode := value.HalfEven
+		if d, ok := args[0].(*value.Decimal); ok {
+			mode = d.Mode()
+		}
+		if len(args) == 3 {
+			s, err := asString("decimal", args[2])
+			if err != nil {
+				return nil, err
+			}
+			mode, err = value.ParseRoundingMode(s.Value())
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		d, err := toDecimal(args[0], mode)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(args) == 1 {
+			if d == nil {
+				return nil, errors.New("decimal of " + args[0].Str() + " needs a scale")
+			}
+			return d, nil
+		}
+
+		scale, err := asInt("decimal", args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		r, _ := value.ToRat(args[0])
+		if d != nil {
+			r = d.Rat()
+		}
+		return value.NewDecimal(r, int(scale), mode)
+	}))
+
+	// (float x) converts any number to a Float
+	env.DefineGlobal("float", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("float", args, 1); err != nil {
+			return nil, err
+		}
+
+		if f, ok := args[0].(*value.FloatClass); ok {
+			return f, nil
+		}
+
+		r, err := asRat("float", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		f, _ := r.Float64()
+		return value.NewFloat(f), nil
+	}))
+}
+
+// toDecimal converts o with its natural scale.
+// Rationals have none, for them the Decimal is nil.
+func toDecimal(o value.Object, mode value.RoundingMode) (*value.Decimal, error) {
+	switch o := o.(type) {
+	case *value.Decimal:
+		return value.NewDecimal(o.Rat(), o.Scale(), mode)
+	case *value.StringClass:
+		return value.ParseDecimal(o.Value(), mode)
+	case *value.IntClass, *value.BigInt:
+		return value.ParseDecimal(o.Str(), mode)
+	case *value.FloatClass:
+		// the shortest representation, so that 0.1 has a scale of 1
+		return value.ParseDecimal(strconv.FormatFloat(o.Value(), 'f', -1, 64), mode)
+	case *value.Rational:
+		return nil, nil
+	}
+
+	return nil, typeError("decimal", "number or String", o)
+}
+
+func asRat(name string, o value.Object) (*big.Rat, error) {
+	if r, ok := value.ToRat(o); ok {
+		return r, nil
+	}
+
+	return nil, typeError(name, "number", o)
+}
@@ -0,0 +1,202 @@
+package execution
+
+import (
+	"interpreter/value"
+	"regexp"
+)
+
+// The string comes first, just like for the string builtins. e.g. (match? s #/[0-9]+/)
+// Wherever a regular expression is expected, a String containing the pattern works as well.
+func buildinRegex(env *Env) {
+	env.DefineGlobal("regex", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("regex", args, 1); err != nil {
+			return nil, err
+		}
+
+		pattern, err := asString("regex", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return value.NewRegex(pattern.Value())
+	}))
+
+	env.DefineGlobal("match?", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		s, re, err := stringAndRegex("match?", args)
+		if err != nil {
+			return nil, err
+		}
+
+		return value.NewBool(re.MatchString(s)), nil
+	}))
+
+	// (find s re) is the first match, or nil
+	env.DefineGlobal("find", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		s, re, err := stringAndRegex("find", args)
+		if err != nil {
+			return nil, err
+		}
+
+		loc := re.FindStringIndex(s)
+		if loc == nil {
+			return value.Nil(), nil
+		}
+		return value.NewString(s[loc[0]:loc[1]]), nil
+	}))
+
+	env.DefineGlobal("find-all", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		s, re, err := stringAndRegex("find-all", args)
+		if err != nil {
+			return nil, err
+		}
+
+		return stringArray(re.FindAllString(s, -1)), nil
+	}))
+
+	// (captures s re) returns the groups of the first match, or nil.
+	// Without named groups, this is an array, starting with the whole match.
+	// With named groups, it's a map. Named groups are found under their name, all others under their index.
+	// Groups that didn't participate in the match are nil.
+	env.DefineGlobal("captures", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		s, re, err := stringAndRegex("captures", args)
+		if err != nil {
+			return nil, err
+		}
+
+		loc := re.FindStringSubmatchIndex(s)
+		if loc == nil {
+			return value.Nil(), nil
+		}
+
+		return captures(re, s, loc)
+	}))
+
+	// (replace-re s re replacement)
+	// replacement is either a String, where $1 or ${name} refer to groups,
+	// or a function, called with the match followed by the groups, returning the replacement.
+	env.DefineGlobal("replace-re", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("replace-re", args, 3); err != nil {
+			return nil, err
+		}
+
+		s, re, err := stringAndRegex("replace-re", args[:2])
+		if err != nil {
+			return nil, err
+		}
+
+		if replacement, ok := args[2].(*value.StringClass); ok {
+			return value.NewString(re.ReplaceAllString(s, replacement.Value())), nil
+		}
+
+		result := ""
+		last := 0
+		for _, loc := range re.FindAllStringSubmatchIndex(s, -1) {
+			groups := make([]value.Object, 0, len(loc)/2)
+			for i := 0; i < len(loc); i += 2 {
+				groups = append(groups, group(s, loc[i], loc[i+1]))
+			}
+
+			replacement, err := call(env, args[2], groups)
+			if err != nil {
+				return nil, err
+			}
+
+			result += s[last:loc[0]] + replacement.Str()
+			last = loc[1]
+		}
+
+		return value.NewString(result + s[last:]), nil
+	}))
+
+	// (split-re s re) or (split-re s re n), returning at most n parts
+	env.DefineGlobal("split-re", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgsBetween("split-re", args, 2, 3); err != nil {
+			return nil, err
+		}
+
+		s, re, err := stringAndRegex("split-re", args[:2])
+		if err != nil {
+			return nil, err
+		}
+
+		n := int64(-1)
+		if len(args) == 3 {
+			n, err = asInt("split-re", args[2])
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return stringArray(re.Split(s, int(n))), nil
+	}))
+}
+
+func captures(re *regexp.Regexp, s string, loc []int) (value.Object, error) {
+	names := re.SubexpNames()
+
+	named := false
+	for _, name := range names {
+		if name != "" {
+			named = true
+		}
+	}
+
+	if !named {
+		groups := make([]value.Object, 0, len(names))
+		for i := range names {
+			groups = append(groups, group(s, loc[2*i], loc[2*i+1]))
+		}
+		return value.NewArrayOf(groups), nil
+	}
+
+	m := value.NewMap()
+	for i, name := range names {
+		var key value.Object = value.NewInt(int64(i))
+		if name != "" {
+			key = value.NewString(name)
+		}
+
+		if err := m.Set(key, group(s, loc[2*i], loc[2*i+1])); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// group returns the text of a group, or nil if it didn't match
+func group(s string, from int, to int) value.Object {
+	if from < 0 {
+		return value.Nil()
+	}
+	return value.NewString(s[from:to])
+}
+
+func asRegex(name string, o value.Object) (*regexp.Regexp, error) {
+	switch o := o.(type) {
+	case *value.Regex:
+		return o.Regexp(), nil
+	case *value.StringClass:
+		return regexp.Compile(o.Value())
+	}
+
+	return nil, typeError(name, "Regex", o)
+}
+
+// stringAndRegex expects two arguments, a String followed by a regular expression
+func stringAndRegex(name string, args []value.Object) (string, *regexp.Regexp, error) {
+	if err := expectArgs(name, args, 2); err != nil {
+		return "", nil, err
+	}
+
+	s, err := asString(name, args[0])
+	if err != nil {
+		return "", nil, err
+	}
+
+	re, err := asRegex(name, args[1])
+	if err != nil {
+		return "", nil, err
+	}
+
+	return s.Value(), re, nil
+}
@@ -0,0 +1,375 @@
+package execution
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"interpreter/ast"
+	"interpreter/value"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// shellResult is what running a command returns, (out r) is its output without the trailing newlines
+var shellResult = newClassInfo("ShellResult", "out", "err", "code")
+
+// commandOptions are given as keywords after the arguments, e.g. (sh "false" :check false)
+type commandOptions struct {
+	// a non-zero exit code fails, unless check is false
+	check bool
+	// quiet doesn't show the output in the terminal, it's still captured
+	quiet bool
+	// merge sends stderr to wherever stdout goes, like 2>&1
+	merge bool
+}
+
+type command struct {
+	argv []string
+	opts commandOptions
+}
+
+// pipeline is a chain of commands, the output of each one is the input of the next
+type pipeline struct {
+	commands []command
+
+	// the input of the first command is a file, a value or the terminal
+	inputFile string
+	input     *string
+
+	// the output of the last command is written to a file instead of the terminal
+	outputFile   string
+	appendOutput bool
+}
+
+func buildinShell(env *Env) {
+	// (sh "git" "status") runs git status, just like `git status`
+	env.DefineGlobal("sh", &envFunction{func(env *Env, args []value.Object) (value.Object, error) {
+		cmd, err := commandOf(args)
+		if err != nil {
+			return nil, err
+		}
+		return runPipeline(env, &pipeline{commands: []command{cmd}})
+	}})
+}
+
+// commandOf splits the arguments of sh into the command and the options after it
+func commandOf(args []value.Object) (command, error) {
+	cmd := command{argv: make([]string, 0, len(args)), opts: commandOptions{check: true}}
+
+	for i := 0; i < len(args); i++ {
+		kw, ok := args[i].(*value.Keyword)
+		if !ok {
+			cmd.argv = append(cmd.argv, args[i].Str())
+			continue
+		}
+
+		if i+1 == len(args) {
+			return cmd, errors.New("sh expects a value after " + kw.Str())
+		}
+		i++
+		switch kw.Name() {
+		case "check":
+			cmd.opts.check = args[i].Boolean()
+		case "quiet":
+			cmd.opts.quiet = args[i].Boolean()
+		case "merge":
+			cmd.opts.merge = args[i].Boolean()
+		default:
+			return cmd, errors.New("sh has no option " + kw.Str() + ", expected :check, :quiet or :merge")
+		}
+	}
+
+	if len(cmd.argv) == 0 {
+		return cmd, errors.New("sh expects a command")
+	}
+	return cmd, nil
+}
+
+// evalCommand runs `...`, pipe and redirections
+func evalCommand(env *Env, expr ast.Expression) (value.Object, error) {
+	p, err := buildPipeline(env, expr)
+	if err != nil {
+		return nil, err
+	}
+	return runPipeline(env, p)
+}
+
+func buildPipeline(env *Env, expr ast.Expression) (*pipeline, error) {
+	switch expr := expr.(type) {
+	case ast.Command:
+		argv := make([]string, len(expr.Arguments))
+		for i, arg := range expr.Arguments {
+			v, err := Eval(env, arg)
+			if err != nil {
+				return nil, err
+			}
+			argv[i] = v.Str()
+		}
+		return &pipeline{commands: []command{{argv: argv, opts: commandOptions{check: true}}}}, nil
+
+	case ast.NamedCall:
+		args, kwargs, err := evalArguments(env, expr.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		cmd, err := commandOf(positional(args, kwargs))
+		if err != nil {
+			return nil, err
+		}
+		return &pipeline{commands: []command{cmd}}, nil
+
+	case ast.Pipe:
+		p := &pipeline{}
+		for i, stage := range expr.Stages {
+			if !ast.IsCommand(stage) {
+				if i != 0 {
+					return nil, errors.New("only the first stage of pipe can be a value")
+				}
+
+				v, err := Eval(env, stage)
+				if err != nil {
+					return nil, err
+				}
+				input, err := inputOf(v)
+				if err != nil {
+					return nil, err
+				}
+				p.input = &input
+				continue
+			}
+
+			s, err := buildPipeline(env, stage)
+			if err != nil {
+				return nil, err
+			}
+			if (len(p.commands) > 0 || p.input != nil) && (s.input != nil || s.inputFile != "") {
+				return nil, errors.New("only the first command of a pipe can read from a file")
+			}
+			if p.outputFile != "" {
+				return nil, errors.New("only the last command of a pipe can write to a file")
+			}
+
+			if len(p.commands) == 0 && p.input == nil {
+				p.input, p.inputFile = s.input, s.inputFile
+			}
+			p.commands = append(p.commands, s.commands...)
+			p.outputFile, p.appendOutput = s.outputFile, s.appendOutput
+		}
+
+		if len(p.commands) == 0 {
+			return nil, errors.New("pipe expects a command")
+		}
+		return p, nil
+
+	case ast.Redirect:
+		p, err := buildPipeline(env, expr.Command)
+		if err != nil {
+			return nil, err
+		}
+
+		file, err := Eval(env, expr.File)
+		if err != nil {
+			return nil, err
+		}
+		path, err := asString(expr.Op, file)
+		if err != nil {
+			return nil, err
+		}
+
+		if expr.Op == "<" {
+			if p.input != nil || p.inputFile != "" {
+				return nil, errors.New("the input of " + p.String() + " is redirected twice")
+			}
+			p.inputFile = path.Value()
+			return p, nil
+		}
+
+		if p.outputFile != "" {
+			return nil, errors.New("the output of " + p.String() + " is redirected twice")
+		}
+		p.outputFile, p.appendOutput = path.Value(), expr.Op == ">>"
+		return p, nil
+	}
+
+	return nil, errors.New("expected a command")
+}
+
+// inputOf is what a value writes to the input of a command, an array is written line by line
+func inputOf(v value.Object) (string, error) {
+	switch v := v.(type) {
+	case *value.StringClass:
+		return v.Value(), nil
+	case *value.Array:
+		input := ""
+		for _, line := range v.Values() {
+			input += line.Str() + "\n"
+		}
+		return input, nil
+	}
+	return "", typeError("pipe", "String or Array", v)
+}
+
+func (p *pipeline) String() string {
+	cmds := make([]string, len(p.commands))
+	for i, c := range p.commands {
+		cmds[i] = strings.Join(c.argv, " ")
+	}
+	return "`" + strings.Join(cmds, " | ") + "`"
+}
+
+// runPipeline starts all commands at once, connected by os pipes, without a shell in between.
+// The output of the last command goes to the terminal and is captured as well.
+// Its exit code is the last non-zero one, a command failing anywhere in the pipe fails it.
+func runPipeline(env *Env, p *pipeline) (value.Object, error) {
+	n := len(p.commands)
+	cmds := make([]*exec.Cmd, n)
+	stderrs := make([]bytes.Buffer, n)
+	var stdout bytes.Buffer
+
+	// the files of the parent are closed once the commands are running
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	var environ []string
+	if env.modules.exportVars {
+		environ = append(os.Environ(), exportedVariables(env)...)
+	}
+	for i, c := range p.commands {
+		cmds[i] = exec.Command(c.argv[0], c.argv[1:]...)
+		cmds[i].Env = environ
+	}
+
+	switch {
+	case p.inputFile != "":
+		f, err := os.Open(p.inputFile)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+		cmds[0].Stdin = f
+	case p.input != nil:
+		cmds[0].Stdin = strings.NewReader(*p.input)
+	default:
+		cmds[0].Stdin = os.Stdin
+	}
+
+	for i := 0; i < n-1; i++ {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, r, w)
+		cmds[i].Stdout, cmds[i+1].Stdin = w, r
+	}
+
+	last := p.commands[n-1]
+	switch {
+	case p.outputFile != "":
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if p.appendOutput {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		f, err := os.OpenFile(p.outputFile, flags, 0644)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+		cmds[n-1].Stdout = f
+	case last.opts.quiet:
+		cmds[n-1].Stdout = &stdout
+	default:
+		cmds[n-1].Stdout = io.MultiWriter(os.Stdout, &stdout)
+	}
+
+	for i, c := range p.commands {
+		switch {
+		case c.opts.merge:
+			cmds[i].Stderr = cmds[i].Stdout
+		case c.opts.quiet:
+			cmds[i].Stderr = &stderrs[i]
+		default:
+			cmds[i].Stderr = io.MultiWriter(os.Stderr, &stderrs[i])
+		}
+	}
+
+	for i, cmd := range cmds {
+		if err := cmd.Start(); err != nil {
+			// the commands already running get the end of their input, once the pipes are closed
+			for _, f := range files {
+				f.Close()
+			}
+			for _, started := range cmds[:i] {
+				started.Wait()
+			}
+			return nil, err
+		}
+	}
+	for _, f := range files {
+		f.Close()
+	}
+	files = nil
+
+	// every command is waited for, even if one of them can't be
+	code, failed := 0, false
+	var waitErr error
+	errs := make([]string, 0, n)
+	for i, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			exit, ok := err.(*exec.ExitError)
+			switch {
+			case !ok:
+				if waitErr == nil {
+					waitErr = err
+				}
+			// the next command stopped reading, e.g. head. Without pipefail, that's no failure
+			case i < n-1 && killedBy(exit, syscall.SIGPIPE):
+			default:
+				code = exitCode(exit)
+				failed = failed || p.commands[i].opts.check
+			}
+		}
+		if s := strings.TrimRight(stderrs[i].String(), "\r\n"); s != "" {
+			errs = append(errs, s)
+		}
+	}
+	if waitErr != nil {
+		return nil, waitErr
+	}
+
+	res, err := shellResult.MakeInstance([]value.Object{
+		value.NewString(strings.TrimRight(stdout.String(), "\r\n")),
+		value.NewString(strings.Join(errs, "\n")),
+		value.NewInt(int64(code)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if failed {
+		return nil, thrownError{
+			message: fmt.Sprint(p.String(), " failed with exit code ", code),
+			value:   res,
+		}
+	}
+	return res, nil
+}
+
+// exitCode is 128 plus the number of the signal for a killed process, like in a shell
+func exitCode(exit *exec.ExitError) int {
+	if status, ok := exit.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return exit.ExitCode()
+}
+
+func killedBy(exit *exec.ExitError, signal syscall.Signal) bool {
+	status, ok := exit.Sys().(syscall.WaitStatus)
+	return ok && status.Signaled() && status.Signal() == signal
+}
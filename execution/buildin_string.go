@@ -0,0 +1,348 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"interpreter/value"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxStringLength bounds the strings built by repeat, so that a typo doesn't exhaust the memory
+const maxStringLength = 1 << 30
+
+// All indices and lengths of strings count runes, not bytes.
+func buildinString(env *Env) {
+	// (substr s from) or (substr s from to)
+	env.DefineGlobal("substr", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgsBetween("substr", args, 2, 3); err != nil {
+			return nil, err
+		}
+
+		s, err := asString("substr", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		from, err := asInt("substr", args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		to := int64(s.Len())
+		if len(args) == 3 {
+			to, err = asInt("substr", args[2])
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return s.Substr(int(from), int(to))
+	}))
+
+	// (split s sep), an empty separator splits into single characters
+	env.DefineGlobal("split", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		strs, err := asStrings("split", args, 2)
+		if err != nil {
+			return nil, err
+		}
+
+		return stringArray(strings.Split(strs[0], strs[1])), nil
+	}))
+
+	// (join arr) or (join arr sep)
+	env.DefineGlobal("join", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgsBetween("join", args, 1, 2); err != nil {
+			return nil, err
+		}
+
+		arr, err := asSequence("join", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		sep := ""
+		if len(args) == 2 {
+			s, err := asString("join", args[1])
+			if err != nil {
+				return nil, err
+			}
+			sep = s.Value()
+		}
+
+		parts := make([]string, 0, arr.Len())
+		for i := 0; i < arr.Len(); i++ {
+			parts = append(parts, arr.At(i).Str())
+		}
+
+		return value.NewString(strings.Join(parts, sep)), nil
+	}))
+
+	// (trim s) removes surrounding whitespace, (trim s chars) the given characters
+	env.DefineGlobal("trim", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgsBetween("trim", args, 1, 2); err != nil {
+			return nil, err
+		}
+
+		strs, err := asStrings("trim", args, len(args))
+		if err != nil {
+			return nil, err
+		}
+
+		if len(strs) == 2 {
+			return value.NewString(strings.Trim(strs[0], strs[1])), nil
+		}
+		return value.NewString(strings.TrimSpace(strs[0])), nil
+	}))
+
+	env.DefineGlobal("upper", stringFunction("upper", strings.ToUpper))
+	env.DefineGlobal("lower", stringFunction("lower", strings.ToLower))
+
+	// (replace s old new) replaces every occurrence
+	env.DefineGlobal("replace", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		strs, err := asStrings("replace", args, 3)
+		if err != nil {
+			return nil, err
+		}
+
+		return value.NewString(strings.ReplaceAll(strs[0], strs[1], strs[2])), nil
+	}))
+
+	env.DefineGlobal("starts-with?", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		strs, err := asStrings("starts-with?", args, 2)
+		if err != nil {
+			return nil, err
+		}
+
+		return value.NewBool(strings.HasPrefix(strs[0], strs[1])), nil
+	}))
+
+	env.DefineGlobal("ends-with?", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		strs, err := asStrings("ends-with?", args, 2)
+		if err != nil {
+			return nil, err
+		}
+
+		return value.NewBool(strings.HasSuffix(strs[0], strs[1])), nil
+	}))
+
+	// (index-of s sub) is -1, if sub is not part of s
+	env.DefineGlobal("index-of", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("index-of", args, 2); err != nil {
+			return nil, err
+		}
+
+		s, err := asString("index-of", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		sub, err := asString("index-of", args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		return value.NewInt(int64(s.IndexOf(sub.Value()))), nil
+	}))
+
+	env.DefineGlobal("chars", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("chars", args, 1); err != nil {
+			return nil, err
+		}
+
+		s, err := asString("chars", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return value.NewArrayOf(s.Chars()), nil
+	}))
+
+	// (bytes s) is the utf-8 encoding of s
+	env.DefineGlobal("bytes", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("bytes", args, 1); err != nil {
+			return nil, err
+		}
+
+		s, err := asString("bytes", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		return value.NewByteArray([]byte(s.Value())), nil
+	}))
+
+	env.DefineGlobal("repeat", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("repeat", args, 2); err != nil {
+			return nil, err
+		}
+
+		s, err := asString("repeat", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		n, err := asInt("repeat", args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		if n < 0 {
+			return nil, errors.New("repeat expects a positive count")
+		}
+		if n > 0 && int64(len(s.Value())) > maxStringLength/n {
+			return nil, errors.New(fmt.Sprint("repeat: a string of ", n, " times ", len(s.Value()), " bytes is too long"))
+		}
+
+		return value.NewString(strings.Repeat(s.Value(), int(n))), nil
+	}))
+
+	// (format "%s is %d years old" name age)
+	// the verbs of go's fmt package are supported, %v uses the same representation as str
+	env.DefineGlobal("format", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if len(args) == 0 {
+			return nil, errors.New("format expects a format string")
+		}
+
+		f, err := asString("format", args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		s, err := format(f.Value(), args[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		return value.NewString(s), nil
+	}))
+}
+
+// format formats every verb of f with its own argument,
+// so that the arguments can't be mistaken for fmt's error markers.
+func format(f string, args []value.Object) (string, error) {
+	var out strings.Builder
+	next := 0
+
+	for i := 0; i < len(f); i++ {
+		if f[i] != '%' {
+			out.WriteByte(f[i])
+			continue
+		}
+
+		// flags, width and precision come before the verb
+		start := i
+		i++
+		for i < len(f) && strings.IndexByte("+-# 0", f[i]) >= 0 {
+			i++
+		}
+		i = skipDigits(f, i)
+		if i < len(f) && f[i] == '.' {
+			i = skipDigits(f, i+1)
+		}
+		if i >= len(f) {
+			return "", errors.New("format: missing verb at the end of " + f)
+		}
+
+		verb, size := utf8.DecodeRuneInString(f[i:])
+		directive := f[start : i+size]
+		i += size - 1
+
+		switch verb {
+		case '%':
+			out.WriteByte('%')
+			continue
+		case '*', '[':
+			return "", errors.New("format: " + directive + " is not supported")
+		}
+
+		if next >= len(args) {
+			return "", errors.New("format: missing argument for " + directive)
+		}
+		arg := formatArgument(args[next])
+		next++
+
+		// fmt reports a verb, that doesn't fit the argument, as %!verb(type=value)
+		s := fmt.Sprintf(directive, arg)
+		if s == fmt.Sprintf("%%!%c(%T=%v)", verb, arg, arg) {
+			return "", errors.New(fmt.Sprint("format: can't use ", directive, " for ", args[next-1].Class()))
+		}
+		out.WriteString(s)
+	}
+
+	if next < len(args) {
+		return "", errors.New(fmt.Sprint("format: ", len(args)-next, " arguments more than verbs in ", f))
+	}
+	return out.String(), nil
+}
+
+func skipDigits(s string, i int) int {
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return i
+}
+
+// formatArgument converts o into the go value fmt expects for its verbs
+func formatArgument(o value.Object) interface{} {
+	switch o := o.(type) {
+	case *value.IntClass:
+		return o.Value()
+	case *value.FloatClass:
+		return o.Value()
+	case *value.BigInt:
+		return o.Big()
+	case *value.StringClass:
+		return o.Value()
+	case *value.BoolClass:
+		return o.Boolean()
+	}
+
+	return o.Str()
+}
+
+// stringFunction wraps a go function on strings, e.g. strings.ToUpper
+func stringFunction(name string, f func(string) string) *value.NativeFunction {
+	return value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		strs, err := asStrings(name, args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return value.NewString(f(strs[0])), nil
+	})
+}
+
+func asString(name string, o value.Object) (*value.StringClass, error) {
+	if s, ok := o.(*value.StringClass); ok {
+		return s, nil
+	}
+
+	return nil, typeError(name, "String", o)
+}
+
+// asStrings expects exactly n arguments, all of them strings
+func asStrings(name string, args []value.Object, n int) ([]string, error) {
+	if err := expectArgs(name, args, n); err != nil {
+		return nil, err
+	}
+
+	strs := make([]string, 0, n)
+	for _, arg := range args {
+		s, err := asString(name, arg)
+		if err != nil {
+			return nil, err
+		}
+		strs = append(strs, s.Value())
+	}
+
+	return strs, nil
+}
+
+func stringArray(strs []string) *value.Array {
+	values := make([]value.Object, 0, len(strs))
+	for _, s := range strs {
+		values = append(values, value.NewString(s))
+	}
+	return value.NewArrayOf(values)
+}
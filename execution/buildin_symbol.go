@@ -0,0 +1,61 @@
+package execution
+
+import (
+	"interpreter/value"
+	"strings"
+)
+
+func buildinSymbol(env *Env) {
+	// (symbol "x") is 'x
+	env.DefineGlobal("symbol", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("symbol", args, 1); err != nil {
+			return nil, err
+		}
+
+		name, err := asName("symbol", args[0])
+		if err != nil {
+			return nil, err
+		}
+		return value.NewSymbol(name), nil
+	}))
+
+	// (keyword "a") and (keyword ":a") are :a
+	env.DefineGlobal("keyword", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("keyword", args, 1); err != nil {
+			return nil, err
+		}
+
+		name, err := asName("keyword", args[0])
+		if err != nil {
+			return nil, err
+		}
+		return value.NewKeyword(strings.TrimPrefix(name, ":")), nil
+	}))
+
+	// (name :a) is "a", (name 'x) is "x"
+	env.DefineGlobal("name", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if err := expectArgs("name", args, 1); err != nil {
+			return nil, err
+		}
+
+		name, err := asName("name", args[0])
+		if err != nil {
+			return nil, err
+		}
+		return value.NewString(name), nil
+	}))
+}
+
+// asName accepts a String, Symbol or Keyword
+func asName(name string, o value.Object) (string, error) {
+	switch o := o.(type) {
+	case *value.StringClass:
+		return o.Value(), nil
+	case *value.Symbol:
+		return o.Name(), nil
+	case *value.Keyword:
+		return o.Name(), nil
+	}
+
+	return "", typeError(name, "String, Symbol or Keyword", o)
+}
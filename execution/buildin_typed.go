@@ -0,0 +1,39 @@
+package execution
+
+import (
+	"interpreter/value"
+)
+
+// constructors for typed arrays
+// (int-array 1 2 3) or (int-array [1 2 3])
+func buildinTyped(env *Env) {
+	env.DefineGlobal("int-array", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		return value.ToIntArray(typedArguments(args))
+	}))
+
+	env.DefineGlobal("float-array", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		return value.ToFloatArray(typedArguments(args))
+	}))
+
+	// (byte-array "text") holds the utf-8 encoding of text
+	env.DefineGlobal("byte-array", value.NewNativeFunction(func(args []value.Object) (value.Object, error) {
+		if len(args) == 1 {
+			if s, ok := args[0].(*value.StringClass); ok {
+				return value.NewByteArray([]byte(s.Value())), nil
+			}
+		}
+
+		return value.ToByteArray(typedArguments(args))
+	}))
+}
+
+// typedArguments converts a single array argument, otherwise all arguments are the elements
+func typedArguments(args []value.Object) value.Sequence {
+	if len(args) == 1 {
+		if seq, ok := args[0].(value.Sequence); ok {
+			return seq
+		}
+	}
+
+	return value.NewArrayOf(args)
+}
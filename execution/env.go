@@ -4,90 +4,160 @@ import (
 	"errors"
 	"fmt"
 	"interpreter/value"
+	"strings"
 )
 
 type Env struct {
-	// Note: this is the point to declare stuff as constant.
-	// e.g. make
-	// globals map[string](value.Object, mutable bool)
-	globals map[string]value.Object
-	locals  map[string]value.Object
+	// bindings are immutable, unless they are declared with var
+	globals map[string]*value.Binding
+	locals  map[string]*value.Binding
+
+	// set while a macro body is evaluated, to rename the bindings its quotes introduce
+	hygienic bool
+
+	// shared by all modules of a program
+	modules *modules
+	// the directory of the module, relative imports start here
+	dir string
+
+	// globals defined by the prelude, the program may define them again
+	prelude map[string]bool
 }
 
 func NewEnv() *Env {
-	return &Env{globals: make(map[string]value.Object), locals: make(map[string]value.Object)}
+	return &Env{
+		globals: make(map[string]*value.Binding),
+		locals:  make(map[string]*value.Binding),
+		modules: newModules(),
+		prelude: make(map[string]bool),
+	}
 }
 
 func (e *Env) NewScope() *Env {
 	return &Env{
 		globals: e.globals,
-		locals:  make(map[string]value.Object),
+		locals:  make(map[string]*value.Binding),
+		modules: e.modules,
+		dir:     e.dir,
+		prelude: e.prelude,
 	}
 }
 
+// DefineGlobal defines an immutable global
 func (e *Env) DefineGlobal(ident string, value value.Object) error {
-	if set, ok := e.globals[ident]; ok && set != nil {
-		return errors.New(fmt.Sprint(ident, "already defined"))
+	return e.defineGlobal(ident, value, false)
+}
+
+// DefineMutableGlobal defines a global that can be reassigned with SetGlobal or set!
+func (e *Env) DefineMutableGlobal(ident string, value value.Object) error {
+	return e.defineGlobal(ident, value, true)
+}
+
+func (e *Env) defineGlobal(ident string, v value.Object, mutable bool) error {
+	if _, ok := e.globals[ident]; ok && !e.prelude[ident] {
+		return errors.New(fmt.Sprint(ident, " already defined"))
 	}
+	delete(e.prelude, ident)
 
-	e.globals[ident] = value
+	e.globals[ident] = &value.Binding{Value: v, Mutable: mutable}
 	return nil
 }
 
 func (e *Env) SetGlobal(ident string, value value.Object) error {
-	if set, ok := e.globals[ident]; !(ok && set != nil) {
-		return errors.New(fmt.Sprint("attempting to assign to undefined variable", ident))
+	b, ok := e.globals[ident]
+	if !ok {
+		return errors.New(fmt.Sprint("attempting to assign to undefined variable ", ident))
 	}
 
-	e.globals[ident] = value
-	return nil
+	return assign(ident, b, value)
 }
 
 func (e *Env) LetIn(ident string, value value.Object, f func(e *Env) (value.Object, error)) (value.Object, error) {
-	v := e.locals[ident]
-	e.locals[ident] = value
+	restore := e.bind(ident, value, false)
 	// I have a feeling that in the end, overshadowing won't work like this.
 	// Because of multihreading etc.
 	// well. just in case I added env as a parameter
 	ret, err := f(e)
 
 	// reset local varible. e.g. remove let binding from scope
-	e.locals[ident] = v
+	restore()
 	return ret, err
 
 }
 
+// bind overshadows ident until restore is called
+func (e *Env) bind(ident string, v value.Object, mutable bool) (restore func()) {
+	previous, ok := e.locals[ident]
+	e.locals[ident] = &value.Binding{Value: v, Mutable: mutable}
+
+	return func() {
+		if ok {
+			e.locals[ident] = previous
+		} else {
+			delete(e.locals, ident)
+		}
+	}
+}
+
 /// Define defines a new variable in local scope
-func (e *Env) SetLocal(ident string, value value.Object) error {
-	if set, ok := e.locals[ident]; ok && set != nil {
+func (e *Env) SetLocal(ident string, v value.Object) error {
+	if _, ok := e.locals[ident]; ok {
 		return errors.New(fmt.Sprint("local variable ", ident, " already defined"))
 	}
 
-	e.locals[ident] = value
+	e.locals[ident] = &value.Binding{Value: v}
 	return nil
 }
 
+// Set assigns to a mutable local or global variable
 func (e *Env) Set(ident string, value value.Object) error {
-	if set, ok := e.locals[ident]; ok && set != nil {
-		e.locals[ident] = value
-		return nil
+	if b, ok := e.locals[ident]; ok {
+		return assign(ident, b, value)
 	}
 
-	if _, ok := e.globals[ident]; ok {
-		e.globals[ident] = value
-		return nil
+	if b, ok := e.globals[ident]; ok {
+		return assign(ident, b, value)
 	}
 
 	return errors.New(fmt.Sprint("attempting to assign to undefined variable ", ident))
 }
 
+func assign(ident string, b *value.Binding, v value.Object) error {
+	if !b.Mutable {
+		return errors.New(fmt.Sprint("can't assign to ", ident, ", it is immutable. Declare it with var to allow set!"))
+	}
+
+	b.Value = v
+	return nil
+}
+
+// Locals returns all local variables currently in scope.
+// The map is a copy, the bindings are shared.
+func (e *Env) Locals() map[string]*value.Binding {
+	locals := make(map[string]*value.Binding, len(e.locals))
+	for ident, b := range e.locals {
+		locals[ident] = b
+	}
+
+	return locals
+}
+
 func (e *Env) Get(ident string) (value.Object, error) {
-	if val, ok := e.locals[ident]; ok && val != nil {
-		return val, nil
+	if b, ok := e.locals[ident]; ok {
+		return b.Value, nil
+	}
+
+	if b, ok := e.globals[ident]; ok {
+		return b.Value, nil
 	}
 
-	if val, ok := e.globals[ident]; ok && val != nil {
-		return val, nil
+	// m/foo is foo of the module imported as m
+	if i := strings.Index(ident, "/"); i > 0 && i < len(ident)-1 {
+		if v, err := e.Get(ident[:i]); err == nil {
+			if m, ok := v.(*value.Module); ok {
+				return m.Get(ident[i+1:])
+			}
+		}
 	}
 
 	return nil, errors.New(fmt.Sprint("reading undefined variable ", ident))
@@ -0,0 +1,87 @@
+package execution
+
+import (
+	"fmt"
+	"interpreter/ast"
+	"interpreter/value"
+)
+
+// thrownError is an error carrying a value, e.g. the result of a failed command
+type thrownError struct {
+	message string
+	value   value.Object
+}
+
+func (t thrownError) Error() string {
+	return t.message
+}
+
+// exitSignal ends the program, it travels up like an error, but nothing catches it
+type exitSignal struct {
+	code int
+}
+
+func (e exitSignal) Error() string {
+	return fmt.Sprint("exit ", e.code)
+}
+
+// ExitCode reports whether err is a call of (exit code)
+func ExitCode(err error) (int, bool) {
+	e, ok := err.(exitSignal)
+	return e.code, ok
+}
+
+// syntaxError is an error in the code itself, found before it is evaluated
+type syntaxError struct {
+	err error
+}
+
+func (s syntaxError) Error() string {
+	return s.err.Error()
+}
+
+// IsSyntaxError reports whether err comes from reading or parsing the code, instead of evaluating it
+func IsSyntaxError(err error) bool {
+	_, ok := err.(syntaxError)
+	return ok
+}
+
+// errorClass is the class of the errors bound by catch, with the message and the thrown value
+var errorClass = newClassInfo("Error", "message", "value")
+
+// newClassInfo creates a class without methods, for values created by the interpreter
+func newClassInfo(name string, fields ...string) *value.ClassInfo {
+	info, err := value.NewClassInfo(name, fields, nil)
+	if err != nil {
+		panic(err)
+	}
+	return &info
+}
+
+// evalTry evaluates the handler with the error bound to its name, if the body fails.
+// break, continue, recur and exit are passed on, they aren't errors.
+func evalTry(env *Env, try ast.Try) (value.Object, error) {
+	res, err := Eval(env, try.Body)
+	if err == nil {
+		return res, nil
+	}
+
+	switch err.(type) {
+	case breakSignal, continueSignal, recurSignal, exitSignal:
+		return nil, err
+	}
+
+	var thrown value.Object = value.Nil()
+	if t, ok := err.(thrownError); ok {
+		thrown = t.value
+	}
+
+	e, err := errorClass.MakeInstance([]value.Object{value.NewString(err.Error()), thrown})
+	if err != nil {
+		return nil, err
+	}
+
+	return env.LetIn(try.Name, e, func(env *Env) (value.Object, error) {
+		return Eval(env, try.Handler)
+	})
+}
@@ -8,51 +8,218 @@ import (
 )
 
 func call(env *Env, caller value.Object, args []value.Object) (value.Object, error) {
+	return callWithKeywords(env, caller, args, nil)
+}
+
+func callWithKeywords(env *Env, caller value.Object, args []value.Object, kwargs []keywordArg) (value.Object, error) {
 	switch caller := caller.(type) {
 	case *value.Function:
-		return callFunction(env, caller, args)
+		return callFunction(env, caller, args, kwargs)
 	case *value.NativeFunction:
-		return caller.Call(args)
+		return caller.Call(positional(args, kwargs))
+	case *envFunction:
+		return caller.fn(env, positional(args, kwargs))
+	case *value.Macro:
+		return nil, errors.New("macro " + caller.Name + " can't be called at runtime, it has to be defined before the code using it")
 	}
 
 	return nil, errors.New("value of type " + caller.Class() + " is not callable")
 }
 
-func callFunction(env *Env, f *value.Function, args []value.Object) (value.Object, error) {
-	if len(args) != len(f.Args) {
-		return nil, errors.New(fmt.Sprint("called function with", len(args), "arguments, expected", len(f.Args)))
+// keywordArg is an argument passed by name, e.g. :b 2 in (f 1 :b 2)
+// at is the number of positional arguments before it
+type keywordArg struct {
+	name  string
+	value value.Object
+	at    int
+}
+
+// evalArguments evaluates the arguments of a call, separating keyword arguments from positional ones.
+// A keyword at the end has no value, so it is a positional argument.
+func evalArguments(env *Env, exprs []ast.Expression) ([]value.Object, []keywordArg, error) {
+	args := make([]value.Object, 0, len(exprs))
+	var kwargs []keywordArg
+
+	for i := 0; i < len(exprs); i++ {
+		if kw, ok := exprs[i].(ast.KeywordLiteral); ok && i+1 < len(exprs) {
+			v, err := Eval(env, exprs[i+1])
+			if err != nil {
+				return nil, nil, err
+			}
+
+			kwargs = append(kwargs, keywordArg{kw.Name, v, len(args)})
+			i++
+			continue
+		}
+
+		v, err := Eval(env, exprs[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		args = append(args, v)
+	}
+
+	return args, kwargs, nil
+}
+
+// positional passes keyword arguments on as a keyword followed by the value, where they were written.
+// e.g. builtins get (get m :a) as m, :a
+func positional(args []value.Object, kwargs []keywordArg) []value.Object {
+	if len(kwargs) == 0 {
+		return args
+	}
+
+	all := make([]value.Object, 0, len(args)+2*len(kwargs))
+	i := 0
+	for _, kw := range kwargs {
+		all = append(all, args[i:kw.at]...)
+		all = append(all, value.NewKeyword(kw.name), kw.value)
+		i = kw.at
+	}
+	return append(all, args[i:]...)
+}
+
+func callFunction(env *Env, f *value.Function, args []value.Object, kwargs []keywordArg) (value.Object, error) {
+	scope, err := bindArguments(env, f, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := Eval(scope, f.Body)
+	return res, escapeSignal(err)
+}
+
+// bindArguments returns the scope f is evaluated in
+func bindArguments(env *Env, f *value.Function, args []value.Object, kwargs []keywordArg) (*Env, error) {
+	// keywords that don't name an argument are values
+	var byName, other []keywordArg
+	for _, kw := range kwargs {
+		if indexOf(f.Args, kw.name) < 0 {
+			other = append(other, kw)
+		} else {
+			byName = append(byName, kw)
+		}
+	}
+	args = positional(args, other)
+
+	if len(args) > len(f.Args) && f.Rest == "" {
+		return nil, errors.New(fmt.Sprint(f.Describe(), " expects at most ", len(f.Args), " arguments, got ", len(args)))
+	}
+
+	named := make(map[string]value.Object, len(byName))
+	for _, kw := range byName {
+		i := indexOf(f.Args, kw.name)
+		if _, ok := named[kw.name]; ok || i < len(args) {
+			return nil, errors.New(f.Describe() + " got multiple values for argument " + kw.name)
+		}
+		named[kw.name] = kw.value
 	}
 
 	env = env.NewScope()
+	if f.Globals != nil {
+		env.globals = f.Globals
+	}
+	for ident, b := range f.Closure {
+		env.locals[ident] = b
+	}
+
+	// arguments overshadow captured variables
+	// defaults are evaluated in order, so that they can refer to the arguments before them
 	for i, ident := range f.Args {
-		// after calling new scope the error cant be null
-		_ = env.Set(ident, args[i])
+		v, ok := named[ident]
+		switch {
+		case i < len(args):
+			v = args[i]
+		case ok:
+		case f.Default(i) != nil:
+			var err error
+			if v, err = Eval(env, f.Default(i)); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, errors.New(fmt.Sprint(f.Describe(), " is missing argument ", ident))
+		}
+
+		env.locals[ident] = &value.Binding{Value: v}
+	}
+
+	if f.Rest != "" {
+		rest := make([]value.Object, 0)
+		if len(args) > len(f.Args) {
+			rest = append(rest, args[len(f.Args):]...)
+		}
+		env.locals[f.Rest] = &value.Binding{Value: value.NewArrayOf(rest)}
 	}
 
-	return Eval(env, f.Body)
+	return env, nil
+}
+
+func indexOf(idents []string, ident string) int {
+	for i, s := range idents {
+		if s == ident {
+			return i
+		}
+	}
+	return -1
 }
 
 func Eval(env *Env, expr ast.Expression) (value.Object, error) {
 	switch expr := expr.(type) {
-	case *ast.ClassDefinition:
-		err := defineClass(env, expr)
+	case ast.ClassDefinition:
+		err := defineClass(env, &expr)
 		if err != nil {
 			return nil, err
 		}
-		return nil, nil
+		return value.Nil(), nil
 
-	case *ast.FunctionDefinition:
-		err := defineFunc(env, expr)
+	case ast.FunctionDefinition:
+		err := defineFunc(env, &expr)
 		if err != nil {
 			return nil, err
 		}
-		return nil, nil
+		return value.Nil(), nil
+
+	case ast.MacroDefinition:
+		err := defineMacro(env, &expr)
+		if err != nil {
+			return nil, err
+		}
+		return value.Nil(), nil
+
+	case ast.Quote:
+		return evalQuote(env, expr)
+
+	case ast.Try:
+		return evalTry(env, expr)
+
+	case ast.WithEnv:
+		return evalWithEnv(env, expr)
+
+	case ast.Command, ast.Pipe, ast.Redirect:
+		return evalCommand(env, expr)
+
+	case ast.Import:
+		return evalImport(env, expr)
+
+	case ast.Constant:
+		return expr.Value.(value.Object), nil
 
 	case ast.DoFlow:
-		var r value.Object
+		var r value.Object = value.Nil()
 		var err error
 
 		for _, statement := range expr.Statements {
+			// variables declared with var live until the end of the block
+			if v, ok := statement.(ast.VarDeclaration); ok {
+				r, err = Eval(env, v.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				defer env.bind(v.Ident, r, true)()
+				continue
+			}
+
 			r, err = Eval(env, statement)
 			if err != nil {
 				return nil, err
@@ -61,6 +228,33 @@ func Eval(env *Env, expr ast.Expression) (value.Object, error) {
 
 		return r, nil
 
+	// (def x 1)
+	case ast.GlobalDefinition:
+		v, err := Eval(env, expr.Value)
+		if err != nil {
+			return nil, err
+		}
+		if err := env.DefineGlobal(expr.Ident, v); err != nil {
+			return nil, err
+		}
+		return value.Nil(), nil
+
+	// (var x 1) on its own, e.g. as the body of a function, ends right away.
+	// Only at the top level of a script it defines a mutable global, see evalTopLevel
+	case ast.VarDeclaration:
+		return Eval(env, expr.Value)
+
+	// (set! x 2)
+	case ast.Assignment:
+		v, err := Eval(env, expr.Value)
+		if err != nil {
+			return nil, err
+		}
+		if err := env.Set(expr.Ident, v); err != nil {
+			return nil, err
+		}
+		return v, nil
+
 	case ast.IfFlow:
 
 		res, err := Eval(env, expr.Condition)
@@ -107,6 +301,39 @@ func Eval(env *Env, expr ast.Expression) (value.Object, error) {
 
 		return r, nil
 
+	case ast.WhileLoop:
+		return evalWhile(env, expr)
+
+	case ast.ForLoop:
+		return evalFor(env, expr)
+
+	case ast.Loop:
+		return evalLoop(env, expr)
+
+	case ast.Recur:
+		args := make([]value.Object, 0, len(expr.Arguments))
+		for _, arg := range expr.Arguments {
+			v, err := Eval(env, arg)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, v)
+		}
+		return nil, recurSignal{args}
+
+	case ast.Break:
+		var v value.Object = value.Nil()
+		if expr.Value != nil {
+			var err error
+			if v, err = Eval(env, expr.Value); err != nil {
+				return nil, err
+			}
+		}
+		return nil, breakSignal{v}
+
+	case ast.Continue:
+		return nil, continueSignal{}
+
 	case ast.NamedCall:
 		return namedCall(env, &expr)
 
@@ -117,6 +344,9 @@ func Eval(env *Env, expr ast.Expression) (value.Object, error) {
 	case ast.IdentLiteral:
 		return env.Get(expr.Value)
 
+	case ast.KeywordLiteral:
+		return value.NewKeyword(expr.Name), nil
+
 	case ast.BoolLiteral:
 		return value.NewBool(expr.Value), nil
 
@@ -126,14 +356,41 @@ func Eval(env *Env, expr ast.Expression) (value.Object, error) {
 	case ast.IntLiteral:
 		return value.NewInt(expr.Value), nil
 
+	case ast.BigIntLiteral:
+		return value.NewBigInt(expr.Value), nil
+
+	case ast.RationalLiteral:
+		return value.NewRational(expr.Value), nil
+
 	case ast.FloatLiteral:
 		return value.NewFloat(expr.Value), nil
 
 	case ast.StringLiteral:
 		return value.NewString(expr.Value), nil
 
+	case ast.RegexLiteral:
+		return value.FromRegexp(expr.Regexp), nil
+
+	case ast.InterpolatedString:
+		str := ""
+		for _, part := range expr.Parts {
+			v, err := Eval(env, part)
+			if err != nil {
+				return nil, err
+			}
+			str += v.Str()
+		}
+		return value.NewString(str), nil
+
 	case ast.LambdaLiteral:
-		return value.NewFunction(expr.Arguments, expr.Body)
+		f, err := value.NewFunction(expr.Signature, expr.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		f.Closure = env.Locals()
+		f.Globals = env.globals
+		return f, nil
 
 	case ast.ArrayLiteral:
 		values := make([]value.Object, 0, len(expr.Values))
@@ -147,6 +404,25 @@ func Eval(env *Env, expr ast.Expression) (value.Object, error) {
 		}
 		return value.NewArray(values...), nil
 
+	case ast.MapLiteral:
+		m := value.NewMap()
+		for i := range expr.Keys {
+			k, err := Eval(env, expr.Keys[i])
+			if err != nil {
+				return nil, err
+			}
+
+			v, err := Eval(env, expr.Values[i])
+			if err != nil {
+				return nil, err
+			}
+
+			if err := m.Set(k, v); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+
 	// (let x y body)
 	case ast.VariableDefiniton:
 		// first evaluate variable assignment
@@ -159,40 +435,111 @@ func Eval(env *Env, expr ast.Expression) (value.Object, error) {
 		return env.LetIn(expr.Ident, val, func(env *Env) (value.Object, error) {
 			return Eval(env, expr.Body)
 		})
+
+	// (let (a 1 [x y] arr) body)
+	case ast.Let:
+		restores := make([]func(), 0, len(expr.Bindings))
+		defer func() {
+			for i := len(restores) - 1; i >= 0; i-- {
+				restores[i]()
+			}
+		}()
+
+		for _, b := range expr.Bindings {
+			val, err := Eval(env, b.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := destructure(env, b.Pattern, val, &restores); err != nil {
+				return nil, err
+			}
+		}
+
+		return Eval(env, expr.Body)
 	}
 
 	return nil, errors.New("unknown expression encountered: " + fmt.Sprintf("%+v", expr))
 }
 
+// destructure binds the variables in pattern to the matching parts of v.
+// Every binding adds a function to restores, that removes it again.
+func destructure(env *Env, pattern ast.Expression, v value.Object, restores *[]func()) error {
+	switch p := pattern.(type) {
+	case ast.IdentPattern:
+		if p.Ident != "_" {
+			*restores = append(*restores, env.bind(p.Ident, v, false))
+		}
+		return nil
+
+	case ast.ArrayPattern:
+		seq, ok := v.(value.Sequence)
+		if !ok {
+			return errors.New("can't destructure " + v.Class() + " " + v.Str() + " as an array")
+		}
+
+		n := len(p.Elements)
+		if seq.Len() < n || (p.Rest == "" && seq.Len() != n) {
+			expected := fmt.Sprint(n)
+			if p.Rest != "" {
+				expected = fmt.Sprint("at least ", n)
+			}
+			return errors.New(fmt.Sprint("can't destructure ", v.Str(), ": expected ", expected, " elements, got ", seq.Len()))
+		}
+
+		for i, element := range p.Elements {
+			if err := destructure(env, element, seq.At(i), restores); err != nil {
+				return err
+			}
+		}
+
+		if p.Rest != "" {
+			rest := seq.Pick(positions(n, seq.Len()))
+			return destructure(env, ast.IdentPattern{Ident: p.Rest}, rest, restores)
+		}
+		return nil
+
+	case ast.ClassPattern:
+		obj, ok := v.(*value.Class)
+		if !ok || obj.Class() != p.Class {
+			return errors.New("can't destructure " + v.Class() + " " + v.Str() + " as " + p.Class)
+		}
+
+		fields := obj.Fields()
+		if len(fields) != len(p.Fields) {
+			return errors.New(fmt.Sprint("can't destructure ", v.Str(), ": ", p.Class, " has ", len(fields), " fields, got ", len(p.Fields), " patterns"))
+		}
+
+		for i, field := range p.Fields {
+			if err := destructure(env, field, fields[i], restores); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return errors.New(fmt.Sprintf("unknown pattern %+v", pattern))
+}
+
 func callExpr(env *Env, expr ast.Call) (value.Object, error) {
 	function, err := Eval(env, expr.Function)
 	if err != nil {
 		return nil, err
 	}
 
-	args := make([]value.Object, 0, len(expr.Arguments))
-	for _, arg := range expr.Arguments {
-		value, err := Eval(env, arg)
-		if err != nil {
-			return nil, err
-		}
-
-		args = append(args, value)
+	args, kwargs, err := evalArguments(env, expr.Arguments)
+	if err != nil {
+		return nil, err
 	}
 
-	return call(env, function, args)
+	return callWithKeywords(env, function, args, kwargs)
 }
 
 func namedCall(env *Env, expr *ast.NamedCall) (value.Object, error) {
 	// Evaluate arguments
-	args := make([]value.Object, 0, len(expr.Arguments))
-	for _, arg := range expr.Arguments {
-		value, err := Eval(env, arg)
-		if err != nil {
-			return nil, err
-		}
-
-		args = append(args, value)
+	args, kwargs, err := evalArguments(env, expr.Arguments)
+	if err != nil {
+		return nil, err
 	}
 
 	// before calling like a function, check if `expr.Function` is defined as a variable
@@ -202,7 +549,7 @@ func namedCall(env *Env, expr *ast.NamedCall) (value.Object, error) {
 		switch obj := args[0].(type) {
 		case *value.Class:
 			// property access
-			if v, ok := obj.Get(ident); ok != nil {
+			if v, err := obj.Get(ident); err == nil {
 				if len(args) > 1 {
 					return nil, errors.New("can't access property " + ident + " of class " + obj.Class() + " by when call arguments")
 				}
@@ -210,11 +557,10 @@ func namedCall(env *Env, expr *ast.NamedCall) (value.Object, error) {
 				return v, nil
 			}
 
-			// method call?
-			if m, ok := obj.Method(ident); ok != nil {
-				callFunction(env, &m, args[1:])
+			// method call? the instance itself is passed as the first argument (self)
+			if m, err := obj.Method(ident); err == nil {
+				return callFunction(env, &m, args, kwargs)
 			}
-			// TODO hardcode other cases for buildin functions (e.g. Array.length)
 		}
 	}
 
@@ -223,7 +569,7 @@ func namedCall(env *Env, expr *ast.NamedCall) (value.Object, error) {
 		return nil, err
 	}
 
-	return call(env, function, args)
+	return callWithKeywords(env, function, args, kwargs)
 }
 
 func defineClass(env *Env, def *ast.ClassDefinition) error {
@@ -232,19 +578,24 @@ func defineClass(env *Env, def *ast.ClassDefinition) error {
 		return err
 	}
 
+	classInfo.SetGlobals(env.globals)
+	classInfo.SetInvoker(func(fn *value.Function, args []value.Object) (value.Object, error) {
+		return callFunction(env, fn, args, nil)
+	})
+
 	constructor := value.NewNativeFunction(classInfo.MakeInstance)
 
-	env.DefineGlobal(def.Name, constructor)
-	return nil
+	return env.DefineGlobal(def.Name, constructor)
 }
 
 func defineFunc(env *Env, def *ast.FunctionDefinition) error {
 	// TODO this is the spot to include information about codeposition in file, row, col
 	// for stacktraces etc.
-	function, err := value.NewFunction(def.Args, def.Body)
+	function, err := value.NewFunction(def.Signature, def.Body)
 	if err != nil {
 		return err
 	}
-	env.DefineGlobal(def.Name, function)
-	return nil
+	function.Name = def.Name
+	function.Globals = env.globals
+	return env.DefineGlobal(def.Name, function)
 }
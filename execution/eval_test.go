@@ -0,0 +1,1220 @@
+package execution_test
+
+import (
+	"fmt"
+	"interpreter/execution"
+	"interpreter/parsing"
+	"interpreter/stdlib"
+	"interpreter/value"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// run evaluates every expression in input and returns the result of the last one
+func run(input string) (value.Object, error) {
+	tokens, err := parsing.Tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	env := execution.NewEnv()
+	execution.Buildin(env)
+
+	return execution.Run(env, tokens)
+}
+
+// expect evaluates each case and compares the Str() of the result
+func expect(t *testing.T, cases [][]string) {
+	t.Helper()
+
+	for _, c := range cases {
+		res, err := run(c[0])
+		if err != nil {
+			t.Error(c[0], "\nunexpected error:", err)
+			continue
+		}
+
+		if res.Str() != c[1] {
+			t.Error(c[0], "\nexpected:", c[1], "\n     got:", res.Str())
+		}
+	}
+}
+
+// expectError checks that each input fails to evaluate
+func expectError(t *testing.T, inputs []string) {
+	t.Helper()
+
+	for _, input := range inputs {
+		if res, err := run(input); err == nil {
+			t.Error(input, "\nexpected an error, got:", res.Str())
+		}
+	}
+}
+
+func TestMaps(t *testing.T) {
+	expect(t, [][]string{
+		{`{"a" 1 "b" [1 2]}`, `{"a" 1 "b" [ 1 2]}`},
+		{`{}`, `{}`},
+		{`(get {"a" 1} "a")`, `1`},
+		{`(get {"a" 1} "b")`, `nil`},
+		{`(get {"a" 1} "b" 7)`, `7`},
+		{`(get {1 "int" 1.5 "float" true "bool" nil "nil"} 1.5)`, `float`},
+		{`(assoc {"a" 1} "a" 2 "b" 3)`, `{"a" 2 "b" 3}`},
+		{`(dissoc {"a" 1 "b" 2 "c" 3} "a" "x")`, `{"b" 2 "c" 3}`},
+		{`(keys {"a" 1 "b" 2})`, `[ a b]`},
+		{`(values {"a" 1 "b" 2})`, `[ 1 2]`},
+		{`(has? {"a" nil} "a")`, `true`},
+		{`(has? {"a" nil} "b")`, `false`},
+		{`(merge {"a" 1 "b" 2} {"a" 3} {"c" 4})`, `{"a" 3 "b" 2 "c" 4}`},
+	})
+
+	expectError(t, []string{
+		`{"a"}`,
+		`{print 2}`,
+		`(get "abc" 0)`,
+		`(assoc {} "a")`,
+	})
+}
+
+func TestEquality(t *testing.T) {
+	expect(t, [][]string{
+		{`(== 1 1)`, `true`},
+		{`(== 1 1.)`, `true`},
+		{`(== 1 "1")`, `false`},
+		{`(== nil nil)`, `true`},
+		{`(== [1 [2 "a"]] [1 [2 "a"]])`, `true`},
+		{`(== [1 2] [1 2 3])`, `false`},
+		{`(== {"a" 1 "b" 2} {"b" 2 "a" 1})`, `true`},
+		{`(!= 1 2)`, `true`},
+		{`(== (hash [1 "a"]) (hash [1 "a"]))`, `true`},
+		{`(== (hash 2) (hash 2.))`, `true`},
+		{`(get {[1 2] "array key"} [1 2])`, `array key`},
+		// array keys are copied, pushing onto the array doesn't change the key
+		{`(def k [1]) (def m {k 1}) (push k 2) [(get m [1]) (get m [1 2]) (len m)]`, `[ 1 nil 1]`},
+		{`(def m {[1] 1}) (push (get (keys m) 0) 2) (get m [1])`, `1`},
+		{`(def k (int-array 1)) (def m (assoc {} k 1)) (push k 2) (get m (int-array 1))`, `1`},
+		// arrays inside of map and class keys as well
+		{`(class P (x)) (let (a [1] k (P a) m {k 1}) (do (push a 2) [(get m (P [1])) (get m k)]))`, `[ 1 nil]`},
+		{`(let (a [1] k {"x" a} m {k 1}) (do (push a 2) [(get m {"x" [1]}) (get m k)]))`, `[ 1 nil]`},
+		{`(get {1 "one"} 1.)`, `one`},
+		{`(len {-9223372036854775808 "a" -9223372036854775808.0 "b"})`, `1`},
+		{`(has? {-9223372036854775808 "a"} -9223372036854775808.0)`, `true`},
+		{`(< 1 2 3)`, `true`},
+		{`(< 1 3 2)`, `false`},
+		{`(>= 2 2.)`, `true`},
+		{`(< "abc" "abd")`, `true`},
+		{`(compare [1 2] [1 3])`, `-1`},
+		{`(compare [1 2 3] [1 2])`, `1`},
+		{`(< false true)`, `true`},
+	})
+
+	expectError(t, []string{
+		`(< 1 "a")`,
+		`(hash print)`,
+		`{print 1}`,
+	})
+}
+
+func TestClassEquality(t *testing.T) {
+	expect(t, [][]string{
+		{`(class Point (x y)) (== (Point 1 2) (Point 1 2))`, `true`},
+		{`(class Point (x y)) (== (Point 1 2) (Point 2 1))`, `false`},
+		{`(class A (x)) (class B (x)) (== (A 1) (B 1))`, `false`},
+		{`(class Point (x y)) (< (Point 1 2) (Point 1 3))`, `true`},
+		{`(class Point (x y)) (get {(Point 1 2) "p"} (Point 1 2))`, `p`},
+
+		// user defined overrides, only x matters
+		{`(class P (x y) fun ==(self other) (== (x self) (x other))) (== (P 1 2) (P 1 3))`, `true`},
+		{`(class P (x y)
+			fun ==(self other) (== (x self) (x other))
+			fun hash(self) (hash (x self)))
+		  (get {(P 1 2) "found"} (P 1 5))`, `found`},
+		{`(class P (x y) fun compare(self other) (compare (y self) (y other))) (< (P 9 1) (P 0 2))`, `true`},
+	})
+}
+
+func TestFunctions(t *testing.T) {
+	expect(t, [][]string{
+		{`(fun id (x) x) (id 7)`, `7`},
+		{`(fun second (a b) a b) (second 1 2)`, `2`},
+		{`((fun (x) x) "lambda")`, `lambda`},
+		// lambdas capture local variables
+		{`(let k 3 (map [1 2] (fun (x) [x k])))`, `[ [ 1 3] [ 2 3]]`},
+		{`(fun adder (n) (fun (x) (get [x n] 1))) ((adder 5) 1)`, `5`},
+	})
+}
+
+func TestParameters(t *testing.T) {
+	expect(t, [][]string{
+		{`(fun f (a ..rest) rest) (f 1 2 3)`, `[ 2 3]`},
+		{`(fun f (a ..rest) rest) (f 1)`, `[]`},
+		{`(fun f (..xs) (len xs)) (f)`, `0`},
+		{`(fun f (a (b 10)) (+ a b)) (f 1)`, `11`},
+		{`(fun f (a (b 10)) (+ a b)) (f 1 2)`, `3`},
+		{`(fun f (a (b (* a 2))) b) (f 4)`, `8`},
+		{`(fun f (a (b 10) (c 20)) [a b c]) (f 1 :c 3)`, `[ 1 10 3]`},
+		{`(fun f (a b) [a b]) (f :b 2 :a 1)`, `[ 1 2]`},
+		{`(fun f (a (b 1) ..rest) [a b rest]) (f 1 2 3 4)`, `[ 1 2 [ 3 4]]`},
+		{`((fun (a (b 2)) (* a b)) 3)`, `6`},
+		{`((fun (..xs) xs) 1 2)`, `[ 1 2]`},
+		{`((fun (a b) (- a b)) :b 1 :a 3)`, `2`},
+		{`(class Acc (n) fun add(self (x 1) ..more) (+ (n self) x (len more))) (add (Acc 10))`, `11`},
+		{`(class Acc (n) fun add(self (x 1) ..more) (+ (n self) x (len more))) (add (Acc 10) 5 0 0)`, `17`},
+		{`(class Acc (n) fun add(self (x 1)) (+ (n self) x)) (add (Acc 10) :x 2)`, `12`},
+		{`(let k 5 ((fun ((x k)) x)))`, `5`},
+	})
+
+	expectError(t, []string{
+		`(fun f (a) a) (f)`,
+		`(fun f (a) a) (f 1 2)`,
+		`(fun f (a) a) (f 1 :b 2)`,
+		`(fun f (a) a) (f 1 :a 2)`,
+		`(fun f (a) a) (f :a 1 :a 2)`,
+		`(fun f ((a 1) b) a)`,
+		`(fun f (a ..rest b) a)`,
+		`(fun f (a ..a) a)`,
+	})
+}
+
+func TestVariables(t *testing.T) {
+	expect(t, [][]string{
+		{`(def x 1) (+ x 1)`, `2`},
+		{`(def x 1) (fun f () x) (f)`, `1`},
+		{`(do (var x 1) (set! x (+ x 1)) x)`, `2`},
+		{`(var counter 0) (set! counter 5) counter`, `5`},
+		{`(var n 0) (fun inc () (set! n (+ n 1))) (inc) (inc) n`, `2`},
+		{`(fun f () (var x 1) (set! x 10) x) (f)`, `10`},
+		// closures share mutable variables
+		{`(fun counter () (var n 0) (fun () (set! n (+ n 1)))) (def c (counter)) (c) (c)`, `2`},
+		// a var ends with its do block
+		{`(let x 1 (do (do (var x 2) (set! x 3)) x))`, `1`},
+		{`(do (var x 1) (var x "a") x)`, `a`},
+		{`(do (var x 1) (set! x 2))`, `2`},
+		// var is only global at the top level
+		{`(fun f () (var x 1)) (f) (f)`, `1`},
+		{`(let y 1 (var x y)) (let y 2 (var x y))`, `2`},
+		{`(fun f (c) (if c (var x 1) nil)) (f true) (f true)`, `1`},
+	})
+
+	expectError(t, []string{
+		`(def x 1) (set! x 2)`,
+		`(def x 1) (def x 2)`,
+		`(let x 1 (set! x 2))`,
+		`(fun f (x) (set! x 2)) (f 1)`,
+		`(set! undefined 1)`,
+		`(set! print 1)`,
+		`(fun print (x) x)`,
+		`(do (do (var x 1)) (set! x 2))`,
+		`(fun f () (var x 1)) (f) x`,
+		`(let y 1 (var x y)) x`,
+		`(def 1 2)`,
+		`(set! 1 2)`,
+		`(set! x)`,
+	})
+}
+
+func TestLet(t *testing.T) {
+	expect(t, [][]string{
+		{`(let x 1 (+ x 1))`, `2`},
+		{`(let x 1 (+ x 5) (+ x 1))`, `2`},
+		{`(let (a 1 b (+ a 1)) (+ a b))`, `3`},
+		{`(let (a 1) (+ a 5) a)`, `1`},
+		{`(let ([x y] [1 2]) (+ x y))`, `3`},
+		{`(let ([x y ..rest] [1 2 3 4]) rest)`, `[ 3 4]`},
+		{`(let ([x ..rest] [1]) rest)`, `[]`},
+		{`(let ([x [y z]] [1 [2 3]]) (+ x y z))`, `6`},
+		{`(let ([_ y] [1 2]) y)`, `2`},
+		{`(let [x y] [1 2] (* x y))`, `2`},
+		{`(let ([a b] (int-array 1 2)) b)`, `2`},
+		{`(let ([x ..rest] (int-array 1 2 3)) rest)`, `(int-array 2 3)`},
+		{`(class Point (x y)) (let ((Point px py) (Point 1 2)) (+ px py))`, `3`},
+		{`(class Point (x y)) (let ([(Point a b) ..ps] [(Point 1 2) (Point 3 4)] [(Point c d)] ps) [a d])`, `[ 1 4]`},
+		// bindings are removed after the body
+		{`(let x 1 (do (let (x 2 y 3) x) x))`, `1`},
+		{`(let (x 1) (fun () x)) 5`, `5`},
+		{`(map [[1 2] [3 4]] (fun (p) (let ([a b] p) (+ a b))))`, `[ 3 7]`},
+	})
+
+	expectError(t, []string{
+		`(let ([x y] [1 2 3]) x)`,
+		`(let ([x y ..rest] [1]) x)`,
+		`(let ([x y] 1) x)`,
+		`(class Point (x y)) (let ((Point a) (Point 1 2)) a)`,
+		`(class Point (x y)) (class Other (x y)) (let ((Point a b) (Other 1 2)) a)`,
+		`(let (a 1 b) a)`,
+		`(let (a 1))`,
+		`(let ((a b) 1) a)`,
+		`(let ([..rest x] [1 2]) x)`,
+		`(let (a 1) b)`,
+	})
+}
+
+func TestLoops(t *testing.T) {
+	expect(t, [][]string{
+		{`(do (var i 0) (while (< i 5) (set! i (+ i 1))) i)`, `5`},
+		{`(while false 1)`, `nil`},
+		{`(do (var sum 0) (for (x in [1 2 3]) (set! sum (+ sum x))) sum)`, `6`},
+		{`(do (var sum 0) (for (x in (lazy-range 100001)) (set! sum (+ sum x))) sum)`, `5000050000`},
+		{`(do (var s "") (for (c in "héllo") (set! s (str c s))) s)`, `olléh`},
+		{`(do (var ks []) (for ([k v] in {"a" 1 "b" 2}) (push ks (str k v))) ks)`, `[ a1 b2]`},
+		{`(do (var xs []) (for (x in (send (chan 3) 1 2 3)) (push xs x) (if (== x 3) (break) nil)) xs)`, `[ 1 2 3]`},
+		{`(let ch (chan 2) (do (send ch 1 2) (close ch) (var xs []) (for (x in ch) (push xs x)) xs))`, `[ 1 2]`},
+		{`(let ch (chan 1) (do (send ch 1) (close ch) [(recv ch) (recv ch)]))`, `[ 1 nil]`},
+		{`(for (x in [1 2 3]) (if (== x 2) (break "found") nil))`, `found`},
+		{`(do (var xs []) (for (x in (range 5)) (if (% x 2) (continue) nil) (push xs x)) xs)`, `[ 0 2 4]`},
+		{`(do (var i 0) (while true (set! i (+ i 1)) (if (> i 3) (break i) nil)))`, `4`},
+		{`(loop (i 0 acc 1) (if (> i 4) acc (recur (+ i 1) (* acc 2))))`, `32`},
+		{`(loop (i 0) (if (< i 100000) (recur (+ i 1)) i))`, `100000`},
+		{`(loop ([x ..rest] [1 2 3] sum 0) (if (== (len rest) 0) (+ sum x) (recur rest (+ sum x))))`, `6`},
+		// the innermost loop handles break
+		{`(do (var n 0) (for (x in [1 2]) (for (y in [1 2 3]) (if (== y 2) (break) (set! n (+ n 1))))) n)`, `2`},
+		// each iteration has its own binding
+		{`(do (var fs []) (for (x in [1 2]) (push fs (fun () x))) ((get fs 0)))`, `1`},
+	})
+
+	expectError(t, []string{
+		`(break)`,
+		`(continue)`,
+		`(recur 1)`,
+		`(fun f () (break)) (for (x in [1]) (f))`,
+		`(loop (i 0) (recur 1 2))`,
+		`(for (x in 1) x)`,
+		`(for (x of [1]) x)`,
+		`(for ([a b] in [1 2]) a)`,
+		`(while true)`,
+		`(let ch (chan) (do (close ch) (close ch)))`,
+		`(let ch (chan 1) (do (close ch) (send ch 1)))`,
+		// nothing could ever make room or send, so they fail instead of blocking
+		`(send (chan) 1)`,
+		`(send (chan 1) 1 2)`,
+		`(recv (chan))`,
+		`(for (x in (send (chan 2) 1)) x)`,
+	})
+}
+
+func TestSymbols(t *testing.T) {
+	expect(t, [][]string{
+		{`:a`, `:a`},
+		{`(type :a)`, `Keyword`},
+		{`(== :a :a)`, `true`},
+		{`(== :a :b)`, `false`},
+		{`(== 'x 'x)`, `true`},
+		{`(== 'x :x)`, `false`},
+		{`(== 'x "x")`, `false`},
+		{`(get {:a 1 'b 2} :a)`, `1`},
+		{`(get {:a 1 'b 2} 'b)`, `2`},
+		{`(has? {:a 1} "a")`, `false`},
+		{`(symbol "x")`, `x`},
+		{`(== (symbol "x") 'x)`, `true`},
+		{`(== (keyword "a") :a)`, `true`},
+		{`(keyword ":a")`, `:a`},
+		{`(name :a)`, `a`},
+		{`(name 'x)`, `x`},
+		{`(sort [:c :a :b])`, `[ :a :b :c]`},
+		// builtins get keywords as values
+		{`(str :a 1)`, `:a1`},
+		// keywords that aren't arguments are passed on
+		{`(fun f (a) a) (f :a)`, `:a`},
+		{`(fun f (..xs) xs) (f :a 1)`, `[ :a 1]`},
+		{`(fun f (a ..xs) [a xs]) (f 1 :c 2)`, `[ 1 [ :c 2]]`},
+		{`(fun f (a (b 0)) [a b]) (f :b 2 :x)`, `[ :x 2]`},
+	})
+
+	expectError(t, []string{
+		`(symbol 1)`,
+		`(name [1])`,
+		`(fun f (a) a) (f 1 :b 2)`,
+	})
+}
+
+func TestMacros(t *testing.T) {
+	expect(t, [][]string{
+		{`'x`, `x`},
+		{`(quote (a b c))`, `(a b c)`},
+		{`'(+ 1 [2 "3"])`, `(+ 1 [ 2 3])`},
+		{`(type 'x)`, `Symbol`},
+		{`(type '(x))`, `List`},
+		{`(len '(a b c))`, `3`},
+		{`(== '(a b) '(a b))`, `true`},
+		{`(let x 2 ~(a ,x ,(+ x 1)))`, `(a 2 3)`},
+		{`(let xs [1 2] ~(a ,@xs b))`, `(a 1 2 b)`},
+		{`(let xs '(1 2) (quasiquote [0 ,@xs]))`, `[ 0 1 2]`},
+		// a quote is data as written, even with unquotes in it
+		{`(let x 2 '(a ,x))`, `(a (unquote x))`},
+		{`(let xs [1] (quote (a ,@xs)))`, `(a (unquote-splicing xs))`},
+		{`(len '(,x))`, `1`},
+		{`~x`, `x`},
+		{`(let x 2 ~(a '(b ,x)))`, `(a (quote (b 2)))`},
+		{`(macro quoted (x) (list 'quote (list x '(unquote x)))) (quoted 1)`, `(1 (unquote x))`},
+		{`(macro unless (c ..body) ~(if ,c nil (do ,@body))) (unless false 1 2)`, `2`},
+		{`(macro unless (c ..body) ~(if ,c nil (do ,@body))) (unless true (undefined))`, `nil`},
+		{`(macro twice (e) ~(do ,e ,e)) (var n 0) (twice (set! n (+ n 1))) n`, `2`},
+		{`(macro infix (a op b) (list op a b)) (infix 1 + 2)`, `3`},
+		// macros can use other macros
+		{`(macro unless (c x) ~(if ,c nil ,x)) (macro when (c x) ~(unless (== ,c false) ,x)) (when true 1)`, `1`},
+		// and are expanded inside of functions
+		{`(macro unless (c x) ~(if ,c nil ,x)) (fun f (n) (unless (== n 0) (* 2 n))) (f 2)`, `4`},
+		{`(macro unless (c x) ~(if ,c nil ,x)) (macroexpand '(unless a b))`, `(if a nil b)`},
+		// the tmp of the macro doesn't capture the tmp of the caller
+		{`(macro swap! (a b) ~(let (tmp ,a) (set! ,a ,b) (set! ,b tmp))) (do (var tmp 1) (var y 2) (swap! tmp y) [tmp y])`, `[ 2 1]`},
+		{`(macro with-x (v body) ~(let x ,v ,body)) (let x 1 (with-x 2 x))`, `1`},
+		{`(macro sum (xs) ~(loop (i 0 acc 0) (if (== i (len ,xs)) acc (recur (+ i 1) (+ acc i))))) (let acc [1 2 3] (sum acc))`, `3`},
+		{`(macro each (x xs body) ~(for (,x in ,xs) ,body)) (var n 0) (each i [1 2] (set! n (+ n i))) n`, `3`},
+		{`(list 'a 1 "b")`, `(a 1 b)`},
+		{`(== (gensym) (gensym))`, `false`},
+	})
+
+	expectError(t, []string{
+		`,x`,
+		`(let x 1 ~(a ,@x))`,
+		`(fun f () (m)) (macro m () 1) (f)`,
+		`(macro m (a) a) (m)`,
+		`(macro m (a))`,
+	})
+}
+
+// runModules writes files into a temporary directory and runs main.lisp
+func runModules(t *testing.T, files map[string]string) (value.Object, error) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tokens, err := parsing.Tokenize(files["main.lisp"])
+	if err != nil {
+		return nil, err
+	}
+
+	env := execution.NewEnv()
+	execution.Buildin(env)
+	env.SetFile(filepath.Join(dir, "main.lisp"))
+	env.SetSearchPath([]string{filepath.Join(dir, "vendor")})
+
+	return execution.Run(env, tokens)
+}
+
+func TestModules(t *testing.T) {
+	files := map[string]string{
+		"lib/math.lisp":     `(fun _twice (x) (* 2 x)) (fun quad (x) (_twice (_twice x))) (def pi 3) (var loads 0) (set! loads (+ loads 1))`,
+		"util.lisp":         `(fun inc (x) (+ x 1)) (fun dec (x) (- x 1)) (macro unless (c x) ~(if ,c nil ,x))`,
+		"vendor/ext.lisp":   `(import "./inner") (def answer inner/value)`,
+		"vendor/inner.lisp": `(def value 42)`,
+		"cycle/a.lisp":      `(import "./b")`,
+		"cycle/b.lisp":      `(import "./a")`,
+		"broken.lisp":       `(undefined)`,
+		"back.lisp":         `(import "./main")`,
+	}
+
+	cases := [][]string{
+		{`(import "lib/math.lisp" as m) (m/quad 3)`, `12`},
+		{`(import "lib/math" as m) m/pi`, `3`},
+		{`(import "lib/math" as m) (import "lib/math.lisp" as n) [(== m n) n/loads]`, `[ true 1]`},
+		{`(import "util") (util/inc 1)`, `2`},
+		{`(import (only inc dec) from "util") (inc (inc (dec 1)))`, `2`},
+		{`(import "util" as u) (u/unless false 5)`, `5`},
+		{`(import "ext") ext/answer`, `42`},
+		{`(import "lib/math" as m) (fun _twice (x) x) (m/quad 1)`, `4`},
+		{`(import "util") (import "util") (util/dec 1)`, `0`},
+	}
+	for _, c := range cases {
+		files["main.lisp"] = c[0]
+		res, err := runModules(t, files)
+		if err != nil {
+			t.Error(c[0], "\nunexpected error:", err)
+			continue
+		}
+		if res.Str() != c[1] {
+			t.Error(c[0], "\nexpected:", c[1], "\n     got:", res.Str())
+		}
+	}
+
+	errors := []string{
+		`(import "lib/math" as m) (m/_twice 1)`,
+		`(import (only _twice) from "lib/math")`,
+		`(import "lib/math" as m) m/missing`,
+		`(import "missing")`,
+		`(import "cycle/a")`,
+		`(import "broken")`,
+		`(import "util") (fun inc (x) x) (import (only inc) from "util")`,
+		`(import "util" with u)`,
+	}
+	for _, input := range errors {
+		files["main.lisp"] = input
+		if res, err := runModules(t, files); err == nil {
+			t.Error(input, "\nexpected an error, got:", res.Str())
+		}
+	}
+
+	// the script is part of the cycle, instead of running a second time
+	for _, input := range []string{`(import "./back")`, `(import "./main")`} {
+		files["main.lisp"] = input
+		_, err := runModules(t, files)
+		if err == nil || !regexp.MustCompile(`import cycle: \S*main\.lisp -> `).MatchString(err.Error()) {
+			t.Error(input, "\nexpected an import cycle starting at main.lisp, got:", err)
+		}
+	}
+}
+
+func TestPrelude(t *testing.T) {
+	expect(t, [][]string{
+		{`(not nil)`, `true`},
+		{`(or-default nil 3)`, `3`},
+		{`(min 3 1 2)`, `1`},
+		{`(max 3)`, `3`},
+		{`(first [1 2 3])`, `1`},
+		{`(last [1 2 3])`, `3`},
+		{`(first [])`, `nil`},
+		{`(rest [1 2 3])`, `[ 2 3]`},
+		{`(rest [])`, `[]`},
+		{`(sum (range 5))`, `10`},
+		{`(product [2 3])`, `6`},
+		{`(find-first [1 2 3 4] (fun (x) (> x 2)))`, `3`},
+		{`(any? [1 2] (fun (x) (> x 1)))`, `true`},
+		{`(all? [1 2] (fun (x) (> x 1)))`, `false`},
+		{`(all? [] (fun (x) false))`, `true`},
+		{`(zip [1 2 3] ["a" "b"])`, `[ [ 1 a] [ 2 b]]`},
+		{`(enumerate ["a"])`, `[ [ 0 a]]`},
+		{`(words "  a b  c ")`, `[ a b c]`},
+		{`(capitalize "hello")`, `Hello`},
+		{`(pad-left "7" 3 "0")`, `007`},
+		{`(pad-right "ab" 1)`, `ab`},
+		{`(blank? " ")`, `true`},
+		{`(unwrap (Ok 1))`, `1`},
+		{`(unwrap-or (Err "no") 2)`, `2`},
+		{`(map-ok (Ok 1) inc)`, `(Ok 2)`},
+		{`(err? (map-ok (Err "no") inc))`, `true`},
+		// the prelude can be imported as modules as well
+		{`(import "std/lists" as l) (l/sum [1 2])`, `3`},
+		// and its names can be defined again
+		{`(fun first (xs) "mine") (first [1])`, `mine`},
+		{`(def sum 1) sum`, `1`},
+		{`(fun first (xs) "mine") (last [1])`, `1`},
+	})
+
+	expectError(t, []string{
+		`(unwrap (Err "no"))`,
+		`(throw "failed")`,
+		`(fun first (x) x) (fun first (x) x)`,
+		`(import "std/missing")`,
+	})
+
+	tokens, err := parsing.Tokenize(`(first [1])`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := execution.NewEnv()
+	env.DisablePrelude()
+	execution.Buildin(env)
+	if res, err := execution.Run(env, tokens); err == nil {
+		t.Error("expected first to be undefined without the prelude, got:", res.Str())
+	}
+}
+
+// the default alias of every module of the standard library must not clash with a builtin
+func TestImportStdlib(t *testing.T) {
+	files, err := stdlib.Files.ReadDir(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(file.Name(), ".lisp")
+		for _, prelude := range []bool{true, false} {
+			tokens, err := parsing.Tokenize(`(import "std/` + name + `")`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			env := execution.NewEnv()
+			if !prelude {
+				env.DisablePrelude()
+			}
+			execution.Buildin(env)
+			if _, err := execution.Run(env, tokens); err != nil {
+				t.Errorf("importing std/%s failed: %v", name, err)
+			}
+		}
+	}
+}
+
+func TestShell(t *testing.T) {
+	expect(t, [][]string{
+		{"(out `echo a  'b c' \"d ${(+ 1 2)}\"`)", `a b c d 3`},
+		{"(let x \"a b\" (out `printf %s ${x}`))", `a b`},
+		{"(out `printf %s a\\ b`)", `a b`},
+		{"(code `true`)", `0`},
+		{`(type (sh "true"))`, `ShellResult`},
+		{`(out (sh "printf" "%s-%s" 1 "x" :quiet true))`, `1-x`},
+		{`(code (sh "sh" "-c" "exit 3" :check false))`, `3`},
+		{`(err (sh "sh" "-c" "echo oops >&2" :quiet true))`, `oops`},
+		{"(try `false` (catch e (code (value e))))", `1`},
+		{"(try `false` (catch e (message e)))", "`false` failed with exit code 1"},
+		{`(try (throw "x") 1 (catch e (value e)))`, `x`},
+		{`(try (/ 1 0) (catch e [(message e) (value e)]))`, `[ division by zero nil]`},
+		{`(try 1 (catch e 2))`, `1`},
+		{`(loop (i 0) (try (if (< i 3) (recur (+ i 1)) i) (catch e -1)))`, `3`},
+	})
+
+	expectError(t, []string{
+		"`false`",
+		"`sh -c \"exit 2\"`",
+		"`no-such-command-here`",
+		"`'open`",
+		`(sh)`,
+		`(sh "true" :nope 1)`,
+		`(try 1)`,
+		`(try 1 (catch 2 3))`,
+	})
+}
+
+func TestPipes(t *testing.T) {
+	dir := t.TempDir()
+	inDir := func(code string) string {
+		return strings.ReplaceAll(code, "DIR", dir)
+	}
+
+	expect(t, [][]string{
+		{`(out (pipe (sh "printf" "b\na\nc") (sh "sort") (sh "head" "-n" "2" :quiet true)))`, "a\nb"},
+		{"(out (pipe \"x\\ny\\nx\" `grep x` (sh \"wc\" \"-l\" :quiet true)))", `2`},
+		{`(out (pipe ["b" "a"] (sh "sort" :quiet true)))`, "a\nb"},
+		{`(out (sh "sh" "-c" "echo e >&2" :merge true :quiet true))`, `e`},
+		{`(code (pipe "x" (sh "grep" "y" :check false)))`, `1`},
+		{"(try (pipe `false` `cat`) (catch e (message e)))", "`false | cat` failed with exit code 1"},
+		// yes is killed by SIGPIPE, once head is done
+		{`(out (pipe (sh "yes") (sh "head" "-1" :quiet true)))`, `y`},
+		{`(code (sh "sh" "-c" "kill -9 $$" :check false))`, `137`},
+		{inDir("(do (> \"DIR/a\" `echo a`) (>> \"DIR/a\" `echo b`) (out (< \"DIR/a\" (sh \"cat\" :quiet true))))"), "a\nb"},
+		{inDir("(do (> \"DIR/b\" (pipe \"z\\ny\" `sort`)) (out (< \"DIR/b\" (sh \"cat\" :quiet true))))"), "y\nz"},
+		{inDir("(out (> \"DIR/c\" `echo hidden`))"), ``},
+		// comparisons still work
+		{`(> 2 1)`, `true`},
+		{`(< "a" "b")`, `true`},
+	})
+
+	expectError(t, []string{
+		"(pipe `cat` \"x\")",
+		"(pipe 1 `cat`)",
+		"(pipe \"x\")",
+		"(pipe `echo` (< \"/dev/null\" `cat`))",
+		"(> 1 `true`)",
+		"(< \"/no/such/file\" `cat`)",
+		"(pipe `no-such-command-here` `cat`)",
+	})
+}
+
+func TestEnvironment(t *testing.T) {
+	os.Setenv("INTERPRETER_TEST", "set")
+	defer os.Unsetenv("INTERPRETER_TEST")
+	defer os.Unsetenv("INTERPRETER_TEST_2")
+
+	expect(t, [][]string{
+		{`(env-get "INTERPRETER_TEST")`, `set`},
+		{`(env-get "INTERPRETER_NOT_SET")`, `nil`},
+		{`(env-get "INTERPRETER_NOT_SET" "default")`, `default`},
+		{`(env-set! "INTERPRETER_TEST_2" 12) (env-get "INTERPRETER_TEST_2")`, `12`},
+		{`(out (sh "sh" "-c" "echo $INTERPRETER_TEST_2" :quiet true))`, `12`},
+		{`(env-set! "INTERPRETER_TEST_2" nil) (env-get "INTERPRETER_TEST_2")`, `nil`},
+		{`(with-env {"INTERPRETER_TEST" [1 {:a "b"}]} (env-get "INTERPRETER_TEST"))`, `[1,{"a":"b"}]`},
+		{`(with-env {:INTERPRETER_TEST nil :INTERPRETER_TEST_2 "x"} [(env-get "INTERPRETER_TEST") (env-get "INTERPRETER_TEST_2")])`, `[ nil x]`},
+		{`(with-env {"INTERPRETER_TEST" 1} 2) [(env-get "INTERPRETER_TEST") (env-get "INTERPRETER_TEST_2")]`, `[ set nil]`},
+		{`(try (with-env {"INTERPRETER_TEST" 1} (throw "x")) (catch e (env-get "INTERPRETER_TEST")))`, `set`},
+		// variables are only exported on request
+		{`(def my-var 1) (out (sh "sh" "-c" "echo $my_var" :quiet true))`, ``},
+		{`(export-vars! true)
+		  (class Point (x y))
+		  (def my-var 1)
+		  (def p (Point 1.5 :a))
+		  (fun f () 1)
+		  (let x "a b" (out (sh "sh" "-c" "echo $my_var $x $p $f" :quiet true)))`, `1 a b {"x":1.5,"y":":a"}`},
+	})
+
+	expectError(t, []string{
+		`(env-get 1)`,
+		`(env-set! "INTERPRETER_TEST_2" (fun () 1))`,
+		`(with-env [1 2] 1)`,
+		`(with-env {"X" 1})`,
+	})
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	inDir := func(code string) string {
+		return strings.ReplaceAll(code, "DIR", dir)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	cases := [][]string{
+		{`(write "DIR/a.txt" "one\ntwo\n") (read "DIR/a.txt")`, "one\ntwo\n"},
+		{`(append "DIR/a.txt" "three") (read-lines "DIR/a.txt")`, `[ one two three]`},
+		{`(write "DIR/b.bin" (byte-array 1 2 255)) (read-bytes "DIR/b.bin")`, `(byte-array 1 2 255)`},
+		{`[(exists? "DIR/a.txt") (exists? "DIR/nope")]`, `[ true false]`},
+		{`(size (stat "DIR/b.bin"))`, `3`},
+		{`(let info (stat "DIR") [(name info) (dir? info)])`, "[ " + filepath.Base(dir) + " true]"},
+		{`(mkdir "DIR/x/y/z") (write "DIR/x/y/z/c.go" "") (write "DIR/x/d.go" "") (ls "DIR/x")`, `[ d.go y]`},
+		{`(glob "DIR/x/**/*.go")`, `[ DIR/x/d.go DIR/x/y/z/c.go]`},
+		{`(glob "DIR/*.txt")`, `[ DIR/a.txt]`},
+		{`(glob "DIR/nope/**")`, `[]`},
+		{`(cp "DIR/x" "DIR/copy") (glob "DIR/copy/**/*.go")`, `[ DIR/copy/d.go DIR/copy/y/z/c.go]`},
+		{`(mkdir "DIR/into") (mv "DIR/a.txt" "DIR/into") (ls "DIR/into")`, `[ a.txt]`},
+		{`(cp "DIR/into/a.txt" "DIR/into/b.txt") (read "DIR/into/b.txt")`, "one\ntwo\nthree"},
+		{`(rm "DIR/into/b.txt") (rm "DIR/copy" :recursive true) [(ls "DIR/into") (exists? "DIR/copy")]`, `[ [ a.txt] false]`},
+		{`(cd "DIR/x") [(== (cwd) "DIR/x") (ls)]`, `[ true [ d.go y]]`},
+		{`(exists? (tempdir))`, `true`},
+	}
+	for _, c := range cases {
+		c[0], c[1] = inDir(c[0]), inDir(c[1])
+	}
+	expect(t, cases)
+
+	expectError(t, []string{
+		inDir(`(read "DIR/nope")`),
+		inDir(`(rm "DIR/x")`),
+		inDir(`(write "DIR/n" 1)`),
+		inDir(`(rm "DIR/x" :force true)`),
+		inDir(`(cd "DIR/nope")`),
+		`(glob "[")`,
+		// a directory can't be copied into itself
+		inDir(`(mkdir "DIR/self") (cp "DIR/self" "DIR/self")`),
+		inDir(`(mkdir "DIR/self/sub") (cp "DIR/self" "DIR/self/sub")`),
+		inDir(`(write "DIR/same.txt" "a") (cp "DIR/same.txt" "DIR/same.txt")`),
+	})
+
+	if _, err := os.Stat(filepath.Join(dir, "self", "self")); err == nil {
+		t.Error("expected cp to leave nothing behind, when copying a directory into itself")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "same.txt")); string(data) != "a" {
+		t.Error("expected cp of a file onto itself to keep it, got", string(data))
+	}
+}
+
+func TestIterators(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "log.txt")
+	if err := os.WriteFile(file, []byte("info a\r\nerror b\ninfo c\nerror d\nerror e"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	inFile := func(code string) string {
+		return strings.ReplaceAll(code, "FILE", file)
+	}
+
+	expect(t, [][]string{
+		{inFile(`(collect (lines "FILE"))`), `[ info a error b info c error d error e]`},
+		{inFile(`(collect (take (filter (lines "FILE") (fun (l) (starts-with? l "error"))) 2))`), `[ error b error d]`},
+		{inFile(`(collect (drop (map (lines "FILE") len) 3))`), `[ 7 7]`},
+		{inFile(`(var n 0) (for (l in (lines "FILE")) (set! n (+ n 1))) n`), `5`},
+		{inFile(`(reduce (map (lines "FILE") len) +)`), `33`},
+		{`(type (lines stdin))`, `Iterator`},
+		// only the elements that are needed are computed
+		{`(collect (take (map (iter (lazy-range 1000000000000)) (fun (x) (* x x))) 3))`, `[ 0 1 4]`},
+		{`(var calls 0) (collect (take (map (iter [1 2 3 4]) (fun (x) (set! calls (+ calls 1)))) 2)) calls`, `2`},
+		// take and break close the rest of the iterator, e.g. the file of lines
+		{`(let it (iter [1 2 3]) [(collect (take it 1)) (collect it)])`, `[ [ 1] []]`},
+		{inFile(`(let it (lines "FILE") [(collect (take it 1)) (collect it)])`), `[ [ info a] []]`},
+		{inFile(`(let it (lines "FILE") (do (for (l in it) (break)) (collect it)))`), `[]`},
+		{inFile(`(let it (map (lines "FILE") len) (do (for (n in it) (break)) (collect it)))`), `[]`},
+		{`(collect (iter "ab"))`, `[ a b]`},
+		{`(collect (drop (iter [1 2]) 5))`, `[]`},
+		{`(reduce (iter [1 2 3]) + 10)`, `16`},
+		{`(take [1 2 3] 2)`, `[ 1 2]`},
+		{`(drop (int-array 1 2 3) 1)`, `(int-array 2 3)`},
+		{`(take [1 2] 5)`, `[ 1 2]`},
+	})
+
+	expectError(t, []string{
+		`(lines "/no/such/file")`,
+		`(lines 1)`,
+		`(take [1 2] -1)`,
+		`(collect (map (iter [1 0]) (fun (x) (/ 1 x))))`,
+		`(reduce (iter []) +)`,
+		`(iter 1)`,
+	})
+}
+
+func TestRunLines(t *testing.T) {
+	env := execution.NewEnv()
+	execution.Buildin(env)
+
+	runTokens := func(code string, f func(tokens []parsing.Token) error) {
+		tokens, err := parsing.Tokenize(code)
+		if err == nil {
+			err = f(tokens)
+		}
+		if err != nil {
+			t.Fatal(code, err)
+		}
+	}
+
+	runTokens(`(def seen [])`, func(tokens []parsing.Token) error {
+		_, err := execution.Run(env, tokens)
+		return err
+	})
+	runTokens(`(push seen (upper line))`, func(tokens []parsing.Token) error {
+		return execution.RunLines(env, tokens, strings.NewReader("a\nb\r\n\nc"), false)
+	})
+
+	if v, _ := env.Get("seen"); v.Str() != `[ A B  C]` {
+		t.Error("expected every line to be seen, got", v.Str())
+	}
+
+	// definitions only run with the first line, the globals keep their values
+	runTokens(`(def seen-lines []) (var n 0) (fun count (s) (+ n (len s))) (set! n (count line)) (push seen-lines n)`, func(tokens []parsing.Token) error {
+		return execution.RunLines(env, tokens, strings.NewReader("a\nbb\nccc"), false)
+	})
+
+	if v, _ := env.Get("seen-lines"); v.Str() != `[ 1 3 6]` {
+		t.Error("expected the lengths to add up, got", v.Str())
+	}
+}
+
+func TestCommandLine(t *testing.T) {
+	expect(t, [][]string{
+		{`args`, `[]`},
+		{`(script-path)`, `nil`},
+	})
+
+	env := execution.NewEnv()
+	env.SetArgs([]string{"a", "b c"})
+	execution.Buildin(env)
+	env.SetFile("dir/script.lisp")
+
+	tokens, err := parsing.Tokenize(`[args (script-path)]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := execution.Run(env, tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	abs, _ := filepath.Abs("dir/script.lisp")
+	if expected := "[ [ a b c] " + abs + "]"; res.Str() != expected {
+		t.Error("expected", expected, "got", res.Str())
+	}
+
+	exits := [][]string{
+		{`(exit)`, `0`},
+		{`(exit 3) (print "unreachable")`, `3`},
+		{`(exit 255)`, `255`},
+		{`(fun f () (exit 4)) (f)`, `4`},
+		{`(try (exit 5) (catch e 1))`, `5`},
+		{`(for (x in [1]) (exit 6))`, `6`},
+	}
+	for _, c := range exits {
+		_, err := run(c[0])
+		code, ok := execution.ExitCode(err)
+		if !ok || fmt.Sprint(code) != c[1] {
+			t.Error(c[0], "\nexpected exit", c[1], "got", err)
+		}
+	}
+
+	for _, input := range []string{`(+ 1`, `(if 1 2)`, `(let)`, `(macro inf () '(inf)) (inf)`, `(macro inf () '(do (inf))) (inf)`} {
+		if _, err := run(input); !execution.IsSyntaxError(err) {
+			t.Error(input, "\nexpected a syntax error, got", err)
+		}
+	}
+
+	// nothing runs, if the script has a syntax error
+	env = execution.NewEnv()
+	execution.Buildin(env)
+	tokens, err = parsing.Tokenize("(def x 1)\n(+ 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := execution.Run(env, tokens); !execution.IsSyntaxError(err) || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Error("expected a syntax error in line 2, got", err)
+	}
+	if _, err := env.Get("x"); err == nil {
+		t.Error("expected x to be undefined after a syntax error")
+	}
+	for _, input := range []string{`(/ 1 0)`, `(exit "a")`, `(throw "x")`, `(exit 300)`, `(exit -1)`} {
+		if _, err := run(input); err == nil || execution.IsSyntaxError(err) {
+			t.Error(input, "\nexpected a runtime error, got", err)
+		}
+	}
+}
+
+func TestArrays(t *testing.T) {
+	expect(t, [][]string{
+		{`(len [1 2 3])`, `3`},
+		{`(len {"a" 1})`, `1`},
+		{`(get [1 2 3] 1)`, `2`},
+		{`(get [1 2 3] 5)`, `nil`},
+		{`(get [1 2 3] -1 "missing")`, `missing`},
+		{`(slice [1 2 3 4] 1 3)`, `[ 2 3]`},
+		{`(slice [1 2 3 4] 2)`, `[ 3 4]`},
+		{`(push [1] 2 3)`, `[ 1 2 3]`},
+		{`(let a [1] (do (push a 2) a))`, `[ 1 2]`},
+		{`(concat [1] [] [2 3])`, `[ 1 2 3]`},
+		{`(map [1 2 3] (fun (x) [x]))`, `[ [ 1] [ 2] [ 3]]`},
+		{`(filter [1 2 3 4] (fun (x) (> x 2)))`, `[ 3 4]`},
+		{`(reduce [[1] [2] [3]] concat)`, `[ 1 2 3]`},
+		{`(reduce [1 2 3] (fun (acc x) (push acc x)) [])`, `[ 1 2 3]`},
+		{`(range 3)`, `[ 0 1 2]`},
+		{`(range 2 5)`, `[ 2 3 4]`},
+		{`(range 5 0 -2)`, `[ 5 3 1]`},
+		{`(range 9223372036854775805 9223372036854775807 5)`, `[ 9223372036854775805]`},
+		{`(lazy-range 3)`, `(lazy-range 0 3)`},
+		{`(map (lazy-range 2 5) (fun (x) x))`, `[ 2 3 4]`},
+		{`(== (lazy-range 5 0 -2) [5 3 1])`, `true`},
+		{`(len (lazy-range 5 0 -2))`, `3`},
+		{`(len (lazy-range 3 0))`, `0`},
+		{`(len (lazy-range 0 9223372036854775807 2))`, `4611686018427387904`},
+		{`(len (lazy-range 9223372036854775807 -9223372036854775807 -4611686018427387904))`, `4`},
+		{`(slice (lazy-range 10) 2 4)`, `(int-array 2 3)`},
+		{`(reverse [1 2 3])`, `[ 3 2 1]`},
+		{`(sort [3 1 2])`, `[ 1 2 3]`},
+		{`(sort ["b" "c" "a"] (fun (a b) (compare b a)))`, `[ c b a]`},
+		{`(contains? [1 [2] 3] [2])`, `true`},
+		{`(contains? [1 2 3] 4)`, `false`},
+	})
+
+	expectError(t, []string{
+		`(slice [1 2] 1 5)`,
+		`(sort [1 "a"])`,
+		`(reduce [] concat)`,
+		`(range 1 2 0)`,
+		`(lazy-range 1 2 0)`,
+		`(range 0 9223372036854775807)`,
+		`(range 100000000000)`,
+		`(len (lazy-range -9223372036854775807 9223372036854775807))`,
+		`(map [1] 1)`,
+	})
+}
+
+func TestArithmetic(t *testing.T) {
+	expect(t, [][]string{
+		{`(+ 1 2 3)`, `6`},
+		{`(+)`, `0`},
+		{`(+ 1 2.5)`, `3.5`},
+		{`(- 10 1 2)`, `7`},
+		{`(- 3)`, `-3`},
+		{`(* 2 3 4)`, `24`},
+		{`(/ 6 3)`, `2`},
+		{`(/ 7 2)`, `7/2`},
+		{`(/ -9223372036854775808 -1)`, `9223372036854775808`},
+		{`(% 7 3)`, `1`},
+		{`(pow 2 10)`, `1024`},
+		{`(pow 2 -1)`, `0.5`},
+		{`(fun fib (n) (if (< n 2) n (+ (fib (- n 1)) (fib (- n 2))))) (fib 10)`, `55`},
+	})
+
+	expectError(t, []string{
+		`(+ 1 "a")`,
+		`(/ 1 0)`,
+		`(% 1 0)`,
+		`(/)`,
+	})
+}
+
+func TestBigInts(t *testing.T) {
+	expect(t, [][]string{
+		{`(+ 9223372036854775807 1)`, `9223372036854775808`},
+		{`(- -9223372036854775807 2)`, `-9223372036854775809`},
+		{`(* 4294967296 4294967296)`, `18446744073709551616`},
+		{`(pow 2 100)`, `1267650600228229401496703205376`},
+		{`(pow 3 40)`, `12157665459056928801`},
+		{`(fun fact (n) (if (< n 2) 1 (* n (fact (- n 1))))) (fact 25)`, `15511210043330985984000000`},
+		{`123456789012345678901234567890`, `123456789012345678901234567890`},
+		{`(- 123456789012345678901234567890 123456789012345678901234567889)`, `1`},
+		{`(type (- (+ 9223372036854775807 1) 1))`, `Int`},
+		{`(type 9223372036854775808)`, `BigInt`},
+		{`(/ (pow 2 100) (pow 2 98))`, `4`},
+		{`(/ 36893488147419103232 3)`, `36893488147419103232/3`},
+		{`(type (/ (pow 10 400) 3))`, `Rational`},
+		{`(== (/ (+ (pow 10 400) 1) (pow 10 400)) 1)`, `false`},
+		{`(% (pow 10 30) 7)`, `1`},
+		{`(+ (pow 2 64) 0.5)`, `1.8446744073709552e+19`},
+		{`(== (pow 2 64) 18446744073709551616)`, `true`},
+		{`(< 1 (pow 2 64))`, `true`},
+		{`(> (pow 2 64) 1.5)`, `true`},
+		{`(== (pow 2 64) (pow 2. 64))`, `true`},
+		{`(get {(pow 2 64) "big"} (pow 2. 64))`, `big`},
+		{`(+ (int-array 9223372036854775807 1) 1)`, `[ 9223372036854775808 2]`},
+		{`(format "%d" (pow 2 70))`, `1180591620717411303424`},
+	})
+
+	expectError(t, []string{
+		`(/ (pow 2 64) 0)`,
+		`(pow 2 1000000000000)`,
+		`(pow (pow 2 100) 100000000)`,
+		`(pow 2/3 1000000000000)`,
+		`(% (pow 2 64) 0)`,
+	})
+}
+
+func TestRationals(t *testing.T) {
+	expect(t, [][]string{
+		{`1/3`, `1/3`},
+		{`2/4`, `1/2`},
+		{`4/2`, `2`},
+		{`-1/3`, `-1/3`},
+		{`(+ 1/3 1/6)`, `1/2`},
+		{`(+ 1/3 2/3)`, `1`},
+		{`(type (+ 1/3 2/3))`, `Int`},
+		{`(* 1/3 3)`, `1`},
+		{`(- 1 1/3)`, `2/3`},
+		{`(/ 1/3 2)`, `1/6`},
+		{`(% 7/2 1)`, `1/2`},
+		{`(pow 2/3 2)`, `4/9`},
+		{`(pow 2/3 -2)`, `9/4`},
+		{`(+ 1/2 0.25)`, `0.75`},
+		{`(pow 1/4 0.5)`, `0.5`},
+		{`(rational 2 6)`, `1/3`},
+		{`(rational 0.5)`, `1/2`},
+		{`(numerator 2/6)`, `1`},
+		{`(denominator 2/6)`, `3`},
+		{`(float 1/4)`, `0.25`},
+		{`(== 1/2 0.5)`, `true`},
+		{`(< 1/3 0.34)`, `true`},
+		{`(> 1/3 0)`, `true`},
+		{`(sort [1/2 1/3 0.4 1])`, `[ 1/3 0.4 1/2 1]`},
+		{`(get {1/2 "half"} 0.5)`, `half`},
+		{`(+ [1 2] 1/2)`, `[ 3/2 5/2]`},
+	})
+
+	expectError(t, []string{
+		`1/0`,
+		`(/ 1/3 0)`,
+		`(rational 1 0)`,
+	})
+}
+
+func TestDecimals(t *testing.T) {
+	expect(t, [][]string{
+		{`(decimal "12.50")`, `12.50`},
+		{`(decimal 3)`, `3`},
+		{`(decimal 0.1)`, `0.1`},
+		{`(decimal "-0.05")`, `-0.05`},
+		{`(+ (decimal "0.1") (decimal "0.2"))`, `0.3`},
+		{`(== (+ (decimal "0.1") (decimal "0.2")) (decimal "0.3"))`, `true`},
+		{`(+ (decimal "1.50") 1)`, `2.50`},
+		{`(* (decimal "19.99") 3)`, `59.97`},
+		{`(* (decimal "2.50") (decimal "0.19"))`, `0.4750`},
+		{`(/ (decimal "10.00") 3)`, `3.33`},
+		{`(/ (decimal "10.00" 2 "up") 3)`, `3.34`},
+		{`(decimal 1/3 4)`, `0.3333`},
+		{`(* (decimal "3.00") 1/3)`, `1.00`},
+		{`(decimal "2.345" 2)`, `2.34`},
+		{`(decimal "2.355" 2)`, `2.36`},
+		{`(decimal "2.345" 2 "half-up")`, `2.35`},
+		{`(decimal "2.345" 2 "half-down")`, `2.34`},
+		{`(decimal "-2.341" 2 "floor")`, `-2.35`},
+		{`(decimal "-2.349" 2 "ceiling")`, `-2.34`},
+		{`(decimal "2.349" 2 "down")`, `2.34`},
+		{`(decimal "1.2" 3)`, `1.200`},
+		{`(pow (decimal "1.1") 2)`, `1.21`},
+		{`(- (decimal "1.25"))`, `-1.25`},
+		{`(+ (decimal "0.5") 0.25)`, `0.75`},
+		{`(type (+ (decimal "0.5") 0.25))`, `Float`},
+		{`(type (+ (decimal "0.5") 1/2))`, `Decimal`},
+		{`(== (decimal "1.50") 1.5 3/2)`, `true`},
+		{`(== (decimal "2.00") 2)`, `true`},
+		{`(get {2 "two"} (decimal "2.00"))`, `two`},
+		{`(< (decimal "0.99") 1)`, `true`},
+	})
+
+	expectError(t, []string{
+		`(decimal "abc")`,
+		`(decimal 1/3)`,
+		`(decimal 1 2 "sideways")`,
+		`(decimal 1 -1)`,
+		`(/ (decimal "1.0") 0)`,
+	})
+}
+
+func TestBroadcasting(t *testing.T) {
+	expect(t, [][]string{
+		{`(+ [1 2 3] 1)`, `[ 2 3 4]`},
+		{`(* 2 [1 2 3])`, `[ 2 4 6]`},
+		{`(+ [1 2] [10 20])`, `[ 11 22]`},
+		{`(- [1 2])`, `[ -1 -2]`},
+		// dimensions are lined up from the last one, length 1 is stretched
+		{`(+ [[1 2] [3 4]] [10 20])`, `[ [ 11 22] [ 13 24]]`},
+		{`(+ [[1] [2]] [10 20])`, `[ [ 11 21] [ 12 22]]`},
+		{`(* [[1 2 3]] [[1] [10]])`, `[ [ 1 2 3] [ 10 20 30]]`},
+		{`(+ [1] [1 2 3])`, `[ 2 3 4]`},
+		{`(> [1 2 3] 1)`, `[ false true true]`},
+		{`(<= [1 5] [2 4])`, `[ true false]`},
+		{`(let a [1 2 3] (get a (> a 1)))`, `[ 2 3]`},
+		{`(get ["a" "b" "c"] [2 0])`, `[ c a]`},
+		{`(get [1 2] [])`, `[]`},
+	})
+
+	expectError(t, []string{
+		`(+ [1 2] [1 2 3])`,
+		`(+ [[1 2]] [[1 2 3]])`,
+		`(+ [[1 2] [3 4]] [1 2 3])`,
+		`(get [1 2] [true])`,
+		`(get [1 2] [5])`,
+		`(get [1 2] ["a"])`,
+		`(< [1 2] [3 4] [5 6])`,
+	})
+}
+
+func TestTypedArrays(t *testing.T) {
+	expect(t, [][]string{
+		{`(int-array 1 2 3)`, `(int-array 1 2 3)`},
+		{`(int-array [1 2])`, `(int-array 1 2)`},
+		{`(float-array 1 2.5)`, `(float-array 1 2.5)`},
+		{`(byte-array "hi")`, `(byte-array 104 105)`},
+		{`(+ (int-array 1 2 3) 1)`, `(int-array 2 3 4)`},
+		{`(* (int-array 1 2) (int-array 3 4))`, `(int-array 3 8)`},
+		{`(+ (int-array 1 2) 0.5)`, `(float-array 1.5 2.5)`},
+		{`(/ (float-array 1 2) (int-array 2 4))`, `(float-array 0.5 0.5)`},
+		{`(- (float-array))`, `(float-array)`},
+		// byte arrays become int arrays, so that they can't overflow
+		{`(+ (byte-array 255 2) 1)`, `(int-array 256 3)`},
+		{`(* (byte-array 1 2) 0.5)`, `(float-array 0.5 1)`},
+		{`(+ (int-array 1 2) (int-array 1))`, `(int-array 2 3)`},
+		{`(+ (float-array 1 2) (int-array 1))`, `(float-array 2 3)`},
+		// falls back to boxed values, when the result isn't an Int
+		{`(/ (int-array 1 4) 2)`, `[ 1/2 2]`},
+		{`(+ (int-array 9223372036854775807) 1)`, `[ 9223372036854775808]`},
+		{`(> (int-array 1 2 3) 1)`, `[ false true true]`},
+
+		// generic array functions
+		{`(len (int-array 1 2 3))`, `3`},
+		{`(get (float-array 1 2) 1)`, `2`},
+		{`(slice (int-array 1 2 3) 1)`, `(int-array 2 3)`},
+		{`(push (int-array 1) 2)`, `(int-array 1 2)`},
+		{`(concat (int-array 1) (int-array 2))`, `(int-array 1 2)`},
+		{`(concat (int-array 1) [2])`, `[ 1 2]`},
+		{`(map (int-array 1 2) (fun (x) (* x 2)))`, `[ 2 4]`},
+		{`(filter (int-array 1 2 3) (fun (x) (> x 1)))`, `(int-array 2 3)`},
+		{`(reduce (int-array 1 2 3) +)`, `6`},
+		{`(reverse (byte-array 1 2))`, `(byte-array 2 1)`},
+		{`(sort (float-array 3 1 2))`, `(float-array 1 2 3)`},
+		{`(contains? (int-array 1 2) 2)`, `true`},
+		{`(let a (int-array 1 2 3) (get a (> a 1)))`, `(int-array 2 3)`},
+		{`(== (int-array 1 2) [1 2])`, `true`},
+		{`(get {[1 2] "found"} (int-array 1 2))`, `found`},
+	})
+
+	expectError(t, []string{
+		`(int-array 1.5)`,
+		`(byte-array 256)`,
+		`(push (int-array 1) "a")`,
+		`(+ (int-array 1 2) (int-array 1 2 3))`,
+	})
+}
+
+func TestStrings(t *testing.T) {
+	expect(t, [][]string{
+		{`(len "héllo")`, `5`},
+		{`(len "")`, `0`},
+		{`(substr "héllo" 1 3)`, `él`},
+		{`(substr "héllo" 2)`, `llo`},
+		{`(split "a,b,,c" ",")`, `[ a b  c]`},
+		{`(split "äb" "")`, `[ ä b]`},
+		{`(join ["a" 1 "b"] "-")`, `a-1-b`},
+		{`(join ["a" "b"])`, `ab`},
+		{`(trim "  x y \t")`, `x y`},
+		{`(trim "--x-" "-")`, `x`},
+		{`(upper "héllo")`, `HÉLLO`},
+		{`(lower "ÄB")`, `äb`},
+		{`(replace "a.b.c" "." "/")`, `a/b/c`},
+		{`(starts-with? "hello" "he")`, `true`},
+		{`(ends-with? "hello" "he")`, `false`},
+		{`(index-of "äbc" "c")`, `2`},
+		{`(index-of "abc" "x")`, `-1`},
+		{`(chars "aö")`, `[ a ö]`},
+		{`(bytes "ö")`, `(byte-array 195 182)`},
+		{`(repeat "ab" 3)`, `ababab`},
+		{`(format "%s is %d, %.2f %v" "x" 3 1.5 [1])`, `x is 3, 1.50 [ 1]`},
+		{`(format "%s" "50%!")`, `50%!`},
+		{`(format "%-4s|%+05d|100%%" "ab" 7)`, `ab  |+0007|100%`},
+		{`(format "%s" "%!d(string=a)")`, `%!d(string=a)`},
+	})
+
+	expectError(t, []string{
+		`(substr "abc" 2 5)`,
+		`(upper 1)`,
+		`(repeat "a" -1)`,
+		`(repeat "ab" 4611686018427387904)`,
+		`(format "%d" "a")`,
+		`(format "%s %s" "a")`,
+		`(format "%s" "a" "b")`,
+		`(format "100%")`,
+	})
+}
+
+func TestInterpolation(t *testing.T) {
+	expect(t, [][]string{
+		{`(let name "bob" "hello ${name}!")`, `hello bob!`},
+		{`(let age 41 "you are ${(+ age 1)}")`, `you are 42`},
+		{`"${1}${2}"`, `12`},
+		{`"${[1 2]} and ${{"a" 1}}"`, `[ 1 2] and {"a" 1}`},
+		{`"deep ${(str "in" "${1}")}"`, `deep in1`},
+		{`"costs \${price}"`, `costs ${price}`},
+		{`"just $ money"`, `just $ money`},
+	})
+
+	expectError(t, []string{
+		`"${}"`,
+		`"${1 2}"`,
+		`"${undefined-variable}"`,
+	})
+}
+
+func TestStringLiterals(t *testing.T) {
+	expect(t, [][]string{
+		{`"none"`, `none`},
+		{`"say \"hi\""`, `say "hi"`},
+		{`"\u{48}\x69"`, `Hi`},
+		{`r"C:\new\${x}"`, `C:\new\${x}`},
+		{`(let x 1 """
+			value: ${x}
+			  "quoted"
+			""")`, "value: 1\n  \"quoted\""},
+		{`r"""
+			\d+
+			"""`, `\d+`},
+	})
+
+	expectError(t, []string{
+		`"\q"`,
+		`"unterminated`,
+	})
+}
+
+func TestRegex(t *testing.T) {
+	expect(t, [][]string{
+		{`#/[a-z]+/`, `#/[a-z]+/`},
+		{`#/a\/b/`, `#/a/b/`},
+		{`(regex "[0-9]")`, `#/[0-9]/`},
+		{`(== #/a/ (regex "a"))`, `true`},
+		{`(match? "abc123" #/[0-9]+$/)`, `true`},
+		{`(match? "abc" "^b")`, `false`},
+		{`(find "a1b22" #/[0-9]+/)`, `1`},
+		{`(find "abc" #/[0-9]+/)`, `nil`},
+		{`(find-all "a1b22c333" #/[0-9]+/)`, `[ 1 22 333]`},
+		{`(captures "key=value" #/(\w+)=(\w+)/)`, `[ key=value key value]`},
+		{`(captures "x" #/(a)?x/)`, `[ x nil]`},
+		{`(captures "key=value" #/(?P<k>\w+)=(\w+)/)`, `{0 "key=value" "k" "key" 2 "value"}`},
+		{`(captures "abc" #/\d/)`, `nil`},
+		{`(replace-re "a1b2" #/[0-9]/ "#")`, `a#b#`},
+		{`(replace-re "a=1 b=2" #/(\w)=(\d)/ "$2=$1")`, `1=a 2=b`},
+		{`(replace-re "a1b22" #/[0-9]+/ (fun (m) (len m)))`, `a1b2`},
+		{`(replace-re "x=1 y=2" #/(\w)=(\d)/ (fun (m k v) (upper k)))`, `X Y`},
+		{`(split-re "a1b22c" #/[0-9]+/)`, `[ a b c]`},
+		{`(split-re "a,b,c" #/,/ 2)`, `[ a b,c]`},
+	})
+
+	expectError(t, []string{
+		`#/(/`,
+		`(regex "(")`,
+		`(match? "a" 1)`,
+	})
+}
@@ -0,0 +1,210 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"interpreter/ast"
+	"interpreter/value"
+)
+
+// break, continue and recur travel up as errors, until a loop handles them.
+// Function calls turn them into regular errors, so they can't leave a function.
+
+type breakSignal struct {
+	value value.Object
+}
+
+func (b breakSignal) Error() string {
+	return "break outside of a loop"
+}
+
+type continueSignal struct{}
+
+func (c continueSignal) Error() string {
+	return "continue outside of a loop"
+}
+
+type recurSignal struct {
+	args []value.Object
+}
+
+func (r recurSignal) Error() string {
+	return "recur outside of a loop"
+}
+
+// escapeSignal turns loop signals into regular errors
+func escapeSignal(err error) error {
+	switch err.(type) {
+	case breakSignal, continueSignal, recurSignal:
+		return errors.New(err.Error())
+	}
+	return err
+}
+
+// handleSignal decides how while and for go on after their body returned err.
+// If stop is true, the loop returns result and err.
+func handleSignal(err error) (stop bool, result value.Object, _ error) {
+	switch signal := err.(type) {
+	case nil, continueSignal:
+		return false, nil, nil
+	case breakSignal:
+		return true, signal.value, nil
+	}
+	return true, nil, err
+}
+
+// (while cond body...) is nil, unless it's left with (break value)
+func evalWhile(env *Env, expr ast.WhileLoop) (value.Object, error) {
+	for {
+		cond, err := Eval(env, expr.Condition)
+		if err != nil {
+			return nil, err
+		}
+		if !cond.Boolean() {
+			return value.Nil(), nil
+		}
+
+		_, err = Eval(env, expr.Body)
+		if stop, result, err := handleSignal(err); stop {
+			return result, err
+		}
+	}
+}
+
+// (for (x in coll) body...) is nil, unless it's left with (break value)
+func evalFor(env *Env, expr ast.ForLoop) (value.Object, error) {
+	coll, err := Eval(env, expr.Collection)
+	if err != nil {
+		return nil, err
+	}
+
+	var result value.Object = value.Nil()
+	err = each(coll, func(v value.Object) (bool, error) {
+		restores := make([]func(), 0, 1)
+		err := destructure(env, expr.Pattern, v, &restores)
+		if err == nil {
+			_, err = Eval(env, expr.Body)
+		}
+		for i := len(restores) - 1; i >= 0; i-- {
+			restores[i]()
+		}
+
+		stop, r, err := handleSignal(err)
+		if stop && r != nil {
+			result = r
+		}
+		return !stop, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// each calls f with the elements of coll, until f returns false or an error.
+// Maps yield [key value] arrays, strings their characters and channels the received values until they are closed.
+// Iterators are pulled until they are exhausted, and closed if f stops early.
+func each(coll value.Object, f func(v value.Object) (bool, error)) error {
+	switch coll := coll.(type) {
+	case value.Sequence:
+		for i := 0; i < coll.Len(); i++ {
+			if ok, err := f(coll.At(i)); !ok || err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case *value.StringClass:
+		for _, c := range coll.Value() {
+			if ok, err := f(value.NewString(string(c))); !ok || err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case *value.Map:
+		keys, values := coll.Keys(), coll.Values()
+		for i := range keys {
+			if ok, err := f(value.NewArray(keys[i], values[i])); !ok || err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case *value.Channel:
+		for {
+			v, ok, err := coll.Receive()
+			if !ok || err != nil {
+				return err
+			}
+			if ok, err := f(v); !ok || err != nil {
+				return err
+			}
+		}
+
+	case *value.Iterator:
+		for {
+			v, ok, err := coll.Next()
+			if !ok || err != nil {
+				return err
+			}
+			// stopping early, e.g. with break, closes the file of (lines "file")
+			if ok, err := f(v); !ok || err != nil {
+				coll.Close()
+				return err
+			}
+		}
+	}
+
+	return errors.New("can't iterate over " + coll.Class())
+}
+
+// (loop (i 0) body...) is the value of the body, unless it calls (recur ...) to start over
+func evalLoop(env *Env, expr ast.Loop) (value.Object, error) {
+	values := make([]value.Object, 0, len(expr.Bindings))
+	restores := make([]func(), 0, len(expr.Bindings))
+	restore := func() {
+		for i := len(restores) - 1; i >= 0; i-- {
+			restores[i]()
+		}
+		restores = restores[:0]
+	}
+	defer restore()
+
+	// the initial values are bound one after the other, like in let
+	for _, b := range expr.Bindings {
+		v, err := Eval(env, b.Value)
+		if err != nil {
+			return nil, err
+		}
+		if err := destructure(env, b.Pattern, v, &restores); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+
+	for {
+		result, err := Eval(env, expr.Body)
+		restore()
+
+		switch signal := err.(type) {
+		case nil:
+			return result, nil
+		case breakSignal:
+			return signal.value, nil
+		case recurSignal:
+			if len(signal.args) != len(expr.Bindings) {
+				return nil, errors.New(fmt.Sprint("recur expects ", len(expr.Bindings), " arguments, got ", len(signal.args)))
+			}
+			values = signal.args
+		default:
+			return nil, err
+		}
+
+		for i, b := range expr.Bindings {
+			if err := destructure(env, b.Pattern, values[i], &restores); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
@@ -0,0 +1,524 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"interpreter/ast"
+	"interpreter/parsing"
+	"interpreter/value"
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+// Run evaluates the forms in tokens one after another and returns the value of the last one.
+// All forms are read before the first one is evaluated, so that a syntax error stops the script before it has any effect.
+// Every form is parsed after the forms before it ran, so that the macros they define are expanded.
+func Run(env *Env, tokens []parsing.Token) (value.Object, error) {
+	forms, err := readForms(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	var res value.Object = value.Nil()
+	for _, f := range forms {
+		expr, err := f.parse(env)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err = evalTopLevel(env, expr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// evalTopLevel evaluates a top level form of a script, where (var x 1) defines a mutable global
+func evalTopLevel(env *Env, expr ast.Expression) (value.Object, error) {
+	v, ok := expr.(ast.VarDeclaration)
+	if !ok {
+		return Eval(env, expr)
+	}
+
+	val, err := Eval(env, v.Value)
+	if err != nil {
+		return nil, err
+	}
+	if err := env.DefineMutableGlobal(v.Ident, val); err != nil {
+		return nil, err
+	}
+	return value.Nil(), nil
+}
+
+// form is a top level form of a script, read as data
+type form struct {
+	datum  value.Object
+	tokens []parsing.Token
+	// where the form starts, for syntax errors
+	line int
+}
+
+// readForms reads all forms in tokens
+func readForms(tokens []parsing.Token) ([]form, error) {
+	forms := make([]form, 0)
+	line := 1
+
+	for hasForm(tokens) {
+		for tokens[0].Tag == parsing.Whitespace {
+			line += strings.Count(tokens[0].Span, "\n")
+			tokens = tokens[1:]
+		}
+
+		datum, rest, err := parsing.Read(tokens)
+		if err != nil {
+			return nil, syntaxError{errors.New(fmt.Sprint("line ", line, ": ", err))}
+		}
+
+		f := form{datum, tokens[:len(tokens)-len(rest)], line}
+		for _, t := range f.tokens {
+			line += strings.Count(t.Span, "\n")
+		}
+
+		forms = append(forms, f)
+		tokens = rest
+	}
+
+	return forms, nil
+}
+
+// parse expands the macros in f and parses the result
+func (f form) parse(env *Env) (ast.Expression, error) {
+	expanded, changed, err := expand(env, f.datum, 0)
+	if err != nil {
+		if _, ok := err.(syntaxError); ok {
+			return nil, syntaxError{errors.New(fmt.Sprint("line ", f.line, ": ", err))}
+		}
+		return nil, err
+	}
+
+	// code without macros is parsed as it was written
+	var expr ast.Expression
+	if changed {
+		expr, err = parsing.FromDatum(expanded)
+	} else {
+		expr, _, err = parsing.Parse(f.tokens)
+	}
+	if err != nil {
+		return nil, syntaxError{errors.New(fmt.Sprint("line ", f.line, ": ", err))}
+	}
+
+	return expr, nil
+}
+
+// RunLines runs the script in tokens once for every line of input, with the line bound to line.
+// The definitions at the top of the script, e.g. def, var and fun, only run with the first line,
+// so that their globals keep their values across lines.
+// With print, the value of the last other form is printed for every line, unless it is nil.
+func RunLines(env *Env, tokens []parsing.Token, input io.Reader, print bool) error {
+	forms, err := readForms(tokens)
+	if err != nil {
+		return err
+	}
+
+	// the forms are parsed with the first line, after the macros before them are defined
+	var exprs []ast.Expression
+	first := true
+
+	return each(linesOf(input, nil), func(line value.Object) (bool, error) {
+		res, err := env.LetIn("line", line, func(env *Env) (value.Object, error) {
+			if first {
+				first = false
+				return runFirstLine(env, forms, &exprs)
+			}
+
+			var res value.Object = value.Nil()
+			for _, expr := range exprs {
+				var err error
+				if res, err = Eval(env, expr); err != nil {
+					return nil, err
+				}
+			}
+			return res, nil
+		})
+		if err != nil {
+			return false, err
+		}
+
+		if _, isNil := res.(*value.NilClass); print && !isNil {
+			fmt.Println(res.Str())
+		}
+		return true, nil
+	})
+}
+
+// runFirstLine evaluates all forms and keeps the ones, that run for every line, in exprs
+func runFirstLine(env *Env, forms []form, exprs *[]ast.Expression) (value.Object, error) {
+	var res value.Object = value.Nil()
+
+	for _, f := range forms {
+		expr, err := f.parse(env)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := evalTopLevel(env, expr)
+		if err != nil {
+			return nil, err
+		}
+
+		if !isDefinition(expr) {
+			*exprs = append(*exprs, expr)
+			res = v
+		}
+	}
+
+	return res, nil
+}
+
+// isDefinition reports whether expr defines a global, which can only happen once
+func isDefinition(expr ast.Expression) bool {
+	switch expr.(type) {
+	case ast.GlobalDefinition, ast.VarDeclaration, ast.FunctionDefinition, ast.ClassDefinition, ast.MacroDefinition, ast.Import:
+		return true
+	}
+	return false
+}
+
+func hasForm(tokens []parsing.Token) bool {
+	for _, t := range tokens {
+		if t.Tag != parsing.Whitespace && t.Tag != parsing.EndOfInput {
+			return true
+		}
+	}
+	return false
+}
+
+// maxExpansionDepth bounds how often the expansion of a macro may contain a macro call again,
+// so that a macro that expands to itself is an error instead of overflowing the stack
+const maxExpansionDepth = 1000
+
+// expand replaces all calls of macros in datum by their expansion.
+// It reports whether anything was expanded.
+// depth is the number of expansions datum is part of.
+func expand(env *Env, datum value.Object, depth int) (value.Object, bool, error) {
+	l, ok := datum.(*value.List)
+	if !ok {
+		return rebuild(datum, func(_ int, v value.Object) (value.Object, bool, error) {
+			return expand(env, v, depth)
+		})
+	}
+
+	head := l.Head()
+	if m := macroNamed(env, head); m != nil {
+		if depth >= maxExpansionDepth {
+			return nil, false, syntaxError{errors.New(fmt.Sprint("macro ", head, " is expanded more than ", maxExpansionDepth, " levels deep, it may expand to itself"))}
+		}
+
+		expansion, err := expandMacro(env, m, value.Elements(l)[1:])
+		if err != nil {
+			return nil, false, err
+		}
+
+		// the expansion may use macros as well
+		expanded, _, err := expand(env, expansion, depth+1)
+		return expanded, true, err
+	}
+
+	// parameters, fields and patterns are no calls, even if they look like one
+	params := -1
+	return rebuild(l, func(i int, v value.Object) (value.Object, bool, error) {
+		_, isList := v.(*value.List)
+		switch {
+		case head == "quote":
+			return v, false, nil
+		case head == "quasiquote":
+			return expandUnquoted(env, v, depth)
+		case (head == "fun" || head == "macro") && params < 0 && isList:
+			params = i
+			return v, false, nil
+		case head == "class" && (i == 2 || i > 2 && isSymbol(l.At(i-2), "fun")):
+			return v, false, nil
+		case (head == "let" || head == "loop") && i == 1 && isList:
+			return rebuild(v, func(j int, v value.Object) (value.Object, bool, error) {
+				if j%2 == 0 {
+					return v, false, nil
+				}
+				return expand(env, v, depth)
+			})
+		case head == "for" && i == 1 && isList:
+			return rebuild(v, func(j int, v value.Object) (value.Object, bool, error) {
+				if j != 2 {
+					return v, false, nil
+				}
+				return expand(env, v, depth)
+			})
+		}
+
+		return expand(env, v, depth)
+	})
+}
+
+// expandUnquoted only expands the unquoted parts of quasiquoted code
+func expandUnquoted(env *Env, datum value.Object, depth int) (value.Object, bool, error) {
+	if l, ok := datum.(*value.List); ok && (l.Head() == "unquote" || l.Head() == "unquote-splicing") {
+		return expand(env, l, depth)
+	}
+
+	return rebuild(datum, func(_ int, v value.Object) (value.Object, bool, error) {
+		return expandUnquoted(env, v, depth)
+	})
+}
+
+// rebuild applies f to the elements of a list, array or map, whose keys and values alternate.
+// The datum is only copied, if one of them changed.
+func rebuild(datum value.Object, f func(i int, v value.Object) (value.Object, bool, error)) (value.Object, bool, error) {
+	var elements []value.Object
+	switch d := datum.(type) {
+	case *value.List:
+		elements = value.Elements(d)
+	case *value.Array:
+		elements = d.Values()
+	case *value.Map:
+		values := d.Values()
+		for i, k := range d.Keys() {
+			elements = append(elements, k, values[i])
+		}
+	default:
+		return datum, false, nil
+	}
+
+	changed := false
+	for i, v := range elements {
+		e, c, err := f(i, v)
+		if err != nil {
+			return nil, false, err
+		}
+		elements[i] = e
+		changed = changed || c
+	}
+
+	if !changed {
+		return datum, false, nil
+	}
+
+	switch datum.(type) {
+	case *value.List:
+		return value.NewList(elements), true, nil
+	case *value.Array:
+		return value.NewArrayOf(elements), true, nil
+	}
+
+	m := value.NewMap()
+	for i := 0; i < len(elements); i += 2 {
+		if err := m.Set(elements[i], elements[i+1]); err != nil {
+			return nil, false, err
+		}
+	}
+	return m, true, nil
+}
+
+func macroNamed(env *Env, name string) *value.Macro {
+	if name == "" {
+		return nil
+	}
+
+	v, err := env.Get(name)
+	if err != nil {
+		return nil
+	}
+
+	m, _ := v.(*value.Macro)
+	return m
+}
+
+func isSymbol(datum value.Object, name string) bool {
+	s, ok := datum.(*value.Symbol)
+	return ok && s.Name() == name
+}
+
+// expandMacro calls m with the unevaluated arguments
+func expandMacro(env *Env, m *value.Macro, args []value.Object) (value.Object, error) {
+	scope, err := bindArguments(env, m.Function, args, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	scope.hygienic = true
+	res, err := Eval(scope, m.Body)
+	return res, escapeSignal(err)
+}
+
+func defineMacro(env *Env, def *ast.MacroDefinition) error {
+	function, err := value.NewFunction(def.Signature, def.Body)
+	if err != nil {
+		return err
+	}
+	function.Name = def.Name
+	function.Globals = env.globals
+	return env.DefineGlobal(def.Name, &value.Macro{Function: function})
+}
+
+// evalQuote builds the quoted data, inserting the values of ,x and ,@xs into a quasiquote.
+// Inside of a macro, the names bound by quasiquoted code are replaced by fresh symbols,
+// so that they can't clash with the names in the code passed to the macro.
+func evalQuote(env *Env, quote ast.Quote) (value.Object, error) {
+	datum := quote.Datum.(value.Object)
+
+	renames := make(map[string]value.Object)
+	if env.hygienic && quote.Quasi {
+		names := make(map[string]bool)
+		binders(datum, names)
+		for name := range names {
+			renames[name] = gensym(name)
+		}
+	}
+
+	return quasi(env, datum, renames)
+}
+
+func quasi(env *Env, datum value.Object, renames map[string]value.Object) (value.Object, error) {
+	switch d := datum.(type) {
+	case *value.Unquote:
+		return Eval(env, d.Expr)
+
+	case *value.Symbol:
+		if renamed, ok := renames[d.Name()]; ok {
+			return renamed, nil
+		}
+		return d, nil
+
+	// lists and arrays are always copied, so that changing the result doesn't change the quote
+	case *value.List, *value.Array:
+		seq := d.(value.Sequence)
+		values := make([]value.Object, 0, seq.Len())
+		for i := 0; i < seq.Len(); i++ {
+			if u, ok := seq.At(i).(*value.Unquote); ok && u.Splice {
+				v, err := Eval(env, u.Expr)
+				if err != nil {
+					return nil, err
+				}
+
+				s, ok := v.(value.Sequence)
+				if !ok {
+					return nil, errors.New(",@ expects a sequence, got " + v.Class())
+				}
+				values = append(values, value.Elements(s)...)
+				continue
+			}
+
+			v, err := quasi(env, seq.At(i), renames)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+
+		if _, ok := d.(*value.List); ok {
+			return value.NewList(values), nil
+		}
+		return value.NewArrayOf(values), nil
+
+	case *value.Map:
+		m := value.NewMap()
+		values := d.Values()
+		for i, k := range d.Keys() {
+			k, err := quasi(env, k, renames)
+			if err != nil {
+				return nil, err
+			}
+
+			v, err := quasi(env, values[i], renames)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := m.Set(k, v); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+
+	return datum, nil
+}
+
+// binders collects the names bound by let, loop, for and the parameters of fun in quoted code.
+// Unquoted parts are left out, they come from outside.
+func binders(datum value.Object, names map[string]bool) {
+	seq, ok := datum.(value.Sequence)
+	if m, isMap := datum.(*value.Map); isMap {
+		seq, ok = value.NewArrayOf(append(m.Keys(), m.Values()...)), true
+	}
+	if !ok {
+		return
+	}
+
+	if l, ok := datum.(*value.List); ok && l.Len() > 1 {
+		switch l.Head() {
+		// (let x 1 ...) or (let (a 1 b 2) ...)
+		case "let", "loop":
+			if bindings, ok := l.At(1).(*value.List); ok {
+				for i := 0; i < bindings.Len(); i += 2 {
+					patternNames(bindings.At(i), names)
+				}
+			} else {
+				patternNames(l.At(1), names)
+			}
+
+		// (for (x in xs) ...)
+		case "for":
+			if head, ok := l.At(1).(*value.List); ok && head.Len() > 0 {
+				patternNames(head.At(0), names)
+			}
+
+		// (fun name (a (b 1) ..rest) ...) or (fun (a) ...)
+		case "fun":
+			params, ok := l.At(1).(*value.List)
+			if !ok && l.Len() > 2 {
+				params, ok = l.At(2).(*value.List)
+			}
+			for i := 0; ok && i < params.Len(); i++ {
+				p := params.At(i)
+				if optional, ok := p.(*value.List); ok && optional.Len() > 0 {
+					p = optional.At(0)
+				}
+				patternNames(p, names)
+			}
+		}
+	}
+
+	for i := 0; i < seq.Len(); i++ {
+		binders(seq.At(i), names)
+	}
+}
+
+// patternNames collects the names bound by a let pattern, e.g. x, [a ..rest] or (Point x y)
+func patternNames(pattern value.Object, names map[string]bool) {
+	switch p := pattern.(type) {
+	case *value.Symbol:
+		name := strings.TrimPrefix(p.Name(), "..")
+		if name != "_" && name != "" {
+			names[name] = true
+		}
+	case *value.Array:
+		for i := 0; i < p.Len(); i++ {
+			patternNames(p.At(i), names)
+		}
+	// the first element is the class
+	case *value.List:
+		for i := 1; i < p.Len(); i++ {
+			patternNames(p.At(i), names)
+		}
+	}
+}
+
+var gensyms int64
+
+// gensym returns a symbol, that can't clash with any other name, e.g. tmp#3
+func gensym(prefix string) *value.Symbol {
+	n := atomic.AddInt64(&gensyms, 1)
+	return value.NewSymbol(fmt.Sprint(prefix, "#", n))
+}
@@ -0,0 +1,230 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"interpreter/ast"
+	"interpreter/parsing"
+	"interpreter/stdlib"
+	"interpreter/value"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// modules are loaded once per program, and shared by everything importing them
+type modules struct {
+	searchPath []string
+	loaded     map[string]*value.Module
+	// the files currently being loaded, to detect cycles
+	loading []string
+	// programs without the prelude, e.g. for sandboxes
+	noPrelude bool
+	// variables are passed to commands as environment variables, see ExportVariables
+	exportVars bool
+
+	// the script being run and the arguments passed to it, see SetArgs
+	script string
+	args   []string
+}
+
+func newModules() *modules {
+	return &modules{loaded: make(map[string]*value.Module)}
+}
+
+// SetSearchPath sets the directories searched for imports, that don't start with ./ or ../
+// The directory of the importing file is always searched first.
+func (e *Env) SetSearchPath(dirs []string) {
+	e.modules.searchPath = dirs
+}
+
+// DisablePrelude leaves out the standard library, when Buildin is called afterwards.
+// It applies to all modules of the program, std/ modules can still be imported.
+func (e *Env) DisablePrelude() {
+	e.modules.noPrelude = true
+}
+
+// SetFile sets the file the code in env comes from, relative imports are resolved from its directory.
+// It is the path of the script as well, see script-path.
+// The script is loading until the program ends, so that a module importing it is a cycle,
+// instead of running the script a second time.
+func (e *Env) SetFile(path string) {
+	e.dir = filepath.Dir(path)
+	if abs, err := filepath.Abs(path); err == nil {
+		e.modules.script = abs
+		e.modules.loading = append(e.modules.loading, abs)
+	}
+}
+
+// SetArgs sets the arguments of the script, which it gets as args.
+// It has to be called before Buildin.
+func (e *Env) SetArgs(args []string) {
+	e.modules.args = args
+}
+
+func evalImport(env *Env, imp ast.Import) (value.Object, error) {
+	m, err := importModule(env, imp.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if imp.Only != nil {
+		for _, ident := range imp.Only {
+			v, err := m.Get(ident)
+			if err != nil {
+				return nil, err
+			}
+			if err := defineImport(env, ident, v); err != nil {
+				return nil, err
+			}
+		}
+		return value.Nil(), nil
+	}
+
+	alias := imp.Alias
+	if alias == "" {
+		alias = strings.TrimSuffix(filepath.Base(imp.Path), filepath.Ext(imp.Path))
+	}
+	if err := defineImport(env, alias, m); err != nil {
+		return nil, err
+	}
+	return value.Nil(), nil
+}
+
+// defineImport allows importing the same thing twice
+func defineImport(env *Env, ident string, v value.Object) error {
+	if b, ok := env.globals[ident]; ok && b.Value == v {
+		return nil
+	}
+	return env.DefineGlobal(ident, v)
+}
+
+func importModule(env *Env, path string) (*value.Module, error) {
+	file, std := path, strings.HasPrefix(path, "std/")
+	if !std {
+		var err error
+		if file, err = resolve(env, path); err != nil {
+			return nil, err
+		}
+	}
+
+	loader := env.modules
+	if m, ok := loader.loaded[file]; ok {
+		return m, nil
+	}
+
+	for i, loading := range loader.loading {
+		if loading == file {
+			cycle := append(append([]string(nil), loader.loading[i:]...), file)
+			return nil, errors.New("import cycle: " + strings.Join(cycle, " -> "))
+		}
+	}
+
+	loader.loading = append(loader.loading, file)
+	defer func() {
+		loader.loading = loader.loading[:len(loader.loading)-1]
+	}()
+
+	var data []byte
+	var err error
+	if std {
+		data, err = stdlib.Files.ReadFile(strings.TrimPrefix(path, "std/") + ".lisp")
+		if err != nil {
+			return nil, errors.New("module " + path + " not found in the standard library")
+		}
+	} else if data, err = os.ReadFile(file); err != nil {
+		return nil, err
+	}
+
+	tokens, err := parsing.Tokenize(string(data))
+	if err != nil {
+		return nil, syntaxError{errors.New(fmt.Sprint(file, ": ", err))}
+	}
+
+	// every module has globals of its own
+	// the standard library only uses builtins, as the prelude is made of it
+	moduleEnv := &Env{
+		globals: make(map[string]*value.Binding),
+		locals:  make(map[string]*value.Binding),
+		modules: loader,
+		dir:     filepath.Dir(file),
+		prelude: make(map[string]bool),
+	}
+	if std {
+		moduleEnv.dir = ""
+		builtins(moduleEnv)
+	} else {
+		Buildin(moduleEnv)
+	}
+
+	predefined := make(map[string]*value.Binding, len(moduleEnv.globals))
+	for ident, b := range moduleEnv.globals {
+		predefined[ident] = b
+	}
+
+	if _, err := Run(moduleEnv, tokens); err != nil {
+		switch err.(type) {
+		case exitSignal:
+			return nil, err
+		case syntaxError:
+			return nil, syntaxError{errors.New(fmt.Sprint(file, ": ", err))}
+		}
+		return nil, errors.New(fmt.Sprint(file, ": ", err))
+	}
+
+	// builtins and the prelude are no members, unless the module defined them again
+	members := make(map[string]*value.Binding)
+	for ident, b := range moduleEnv.globals {
+		if predefined[ident] != b {
+			members[ident] = b
+		}
+	}
+
+	m := value.NewModule(file, members)
+	loader.loaded[file] = m
+	return m, nil
+}
+
+// resolve finds the file of a module. "util" is found as util or util.lisp.
+// Paths starting with ./ or ../ are relative to the importing file,
+// others are searched in its directory and then in the search path.
+func resolve(env *Env, path string) (string, error) {
+	var dirs []string
+	switch {
+	case filepath.IsAbs(path):
+		dirs = []string{""}
+	case strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../"):
+		dirs = []string{env.dir}
+	default:
+		dirs = append([]string{env.dir}, env.modules.searchPath...)
+	}
+
+	for _, dir := range dirs {
+		for _, candidate := range []string{path, path + ".lisp"} {
+			file := filepath.Join(dir, candidate)
+			if info, err := os.Stat(file); err == nil && !info.IsDir() {
+				return filepath.Abs(file)
+			}
+		}
+	}
+
+	return "", errors.New("module " + path + " not found")
+}
+
+// prelude defines the members of the prelude modules of the standard library in env
+func prelude(env *Env) {
+	for _, name := range stdlib.Prelude {
+		m, err := importModule(env, "std/"+name)
+		if err != nil {
+			panic("the standard library is broken: " + err.Error())
+		}
+
+		for _, ident := range m.Exports() {
+			v, _ := m.Get(ident)
+			if err := defineImport(env, ident, v); err != nil {
+				panic("the standard library is broken: " + err.Error())
+			}
+			env.prelude[ident] = true
+		}
+	}
+}
@@ -1,85 +1,158 @@
 package main
 
-// All that is writte here is mainly code ment to test out the interpreter.
-// This does not mean to serve as a main to be taken serious.
-// This is a playground.
+// The command line interface of the interpreter.
+//
+//	interpreter [flags] [run] [script | -] [args...]
+//	interpreter [flags] -e code [args...]
+//
+// Without a script, it is read from stdin.
 
 import (
+	"flag"
 	"fmt"
 	"interpreter/execution"
 	"interpreter/parsing"
 	"interpreter/value"
-	"io/ioutil"
+	"io"
 	"os"
+	"path/filepath"
 )
 
-func main() {
-	filename := os.Args[1]
-	data, err := ioutil.ReadFile(filename)
-	if err != nil {
-		fmt.Println("error reading file", err)
-		os.Exit(1)
-	}
+// exit codes, besides the ones passed to (exit code)
+const (
+	exitRuntimeError = 1
+	exitSyntaxError  = 2
+	exitUsage        = 64
+	exitNoInput      = 66
+)
 
-	input := string(data)
+func main() {
+	os.Exit(cli(os.Args[1:]))
+}
 
-	tokens, err := parsing.Tokenize(input)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+func cli(argv []string) int {
+	flags := flag.NewFlagSet("interpreter", flag.ContinueOnError)
+	code := flags.String("e", "", "run code given on the command line, and print its value unless it is nil")
+	noPrelude := flags.Bool("no-prelude", false, "leave out the standard library prelude")
+	eachLine := flags.Bool("n", false, "run the script once for every line of the input, with the line bound to line")
+	printLines := flags.Bool("p", false, "like -n, and print the value of the script for every line, unless it is nil")
+	exportVars := flags.Bool("export-vars", false, "pass the variables of the script to commands as environment variables")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: interpreter [flags] [run] [script | -] [args...]")
+		fmt.Fprintln(flags.Output(), "       interpreter [flags] -e code [args...]")
+		flags.PrintDefaults()
 	}
 
-	env := execution.NewEnv()
-	// TODO populate env with defaults
-	buildin(env)
-
-	ast, rest, err := parsing.Parse(tokens)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	if err := flags.Parse(argv); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return exitUsage
 	}
+	lineMode := *eachLine || *printLines
 
-	res, err := execution.Eval(env, ast)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	args := flags.Args()
+	if len(args) > 0 && args[0] == "run" {
+		args = args[1:]
 	}
 
-	for len(rest) > 0 {
-		ast, rest, err = parsing.Parse(tokens)
+	var source, filename string
+	switch {
+	case *code != "":
+		source = *code
+
+	case len(args) == 0 || args[0] == "-":
+		if lineMode {
+			fmt.Fprintln(os.Stderr, "with -n or -p, stdin is the input, the script has to be a file or given with -e")
+			return exitUsage
+		}
+		if len(args) > 0 {
+			args = args[1:]
+		}
+
+		data, err := io.ReadAll(os.Stdin)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			fmt.Fprintln(os.Stderr, err)
+			return exitNoInput
 		}
+		source = string(data)
 
-		res, err = execution.Eval(env, ast)
+	default:
+		filename, args = args[0], args[1:]
+		data, err := os.ReadFile(filename)
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			fmt.Fprintln(os.Stderr, err)
+			return exitNoInput
 		}
+		source = string(data)
 	}
 
-	fmt.Println(res.Str())
-}
+	tokens, err := parsing.Tokenize(source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitSyntaxError
+	}
 
-func buildin(env *execution.Env) {
-	env.DefineGlobal("true", value.NewBool(true))
-	env.DefineGlobal("false", value.NewBool(false))
+	env := execution.NewEnv()
+	if *noPrelude {
+		env.DisablePrelude()
+	}
+	if *exportVars {
+		env.ExportVariables()
+	}
+	env.SetArgs(args)
+	execution.Buildin(env)
+	if filename != "" {
+		env.SetFile(filename)
+	}
+	// directories searched for imports, separated like PATH
+	if path := os.Getenv("INTERPRETER_PATH"); path != "" {
+		env.SetSearchPath(filepath.SplitList(path))
+	}
 
-	env.DefineGlobal("print", value.NewNativeFunction(func(o []value.Object) (value.Object, error) {
-		for _, obj := range o {
-			fmt.Print(obj.Str())
+	// the input are the files after the script, or stdin
+	if lineMode {
+		input := io.Reader(os.Stdin)
+		if len(args) > 0 {
+			readers := make([]io.Reader, 0, len(args))
+			for _, name := range args {
+				f, err := os.Open(name)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					return exitNoInput
+				}
+				defer f.Close()
+				readers = append(readers, f)
+			}
+			input = io.MultiReader(readers...)
 		}
-		fmt.Println()
 
-		return value.Nil(), nil
-	}))
+		return report(execution.RunLines(env, tokens, input, *printLines))
+	}
 
-	env.DefineGlobal("str", value.NewNativeFunction(func(o []value.Object) (value.Object, error) {
-		s := ""
-		for _, obj := range o {
-			s += obj.Str()
-		}
-		return value.NewString(s), nil
-	}))
+	res, err := execution.Run(env, tokens)
+	if err != nil {
+		return report(err)
+	}
+
+	if _, isNil := res.(*value.NilClass); *code != "" && !isNil {
+		fmt.Println(res.Str())
+	}
+	return 0
+}
+
+// report prints err and returns the exit code for it
+func report(err error) int {
+	if err == nil {
+		return 0
+	}
+	if code, ok := execution.ExitCode(err); ok {
+		return code
+	}
+
+	fmt.Fprintln(os.Stderr, err)
+	if execution.IsSyntaxError(err) {
+		return exitSyntaxError
+	}
+	return exitRuntimeError
 }
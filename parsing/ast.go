@@ -3,7 +3,11 @@ package parsing
 import (
 	"errors"
 	"interpreter/ast"
+	"interpreter/value"
+	"math/big"
+	"regexp"
 	"strconv"
+	"strings"
 )
 
 func Parse(tokens []Token) (ast.Expression, []Token, error) {
@@ -32,12 +36,25 @@ func parse(tokens []Token) (ast.Expression, []Token, error) {
 
 	case Identifier:
 		return ast.IdentLiteral{Value: fst.Span}, rest, nil
+	case Keyword:
+		return ast.KeywordLiteral{Name: fst.Span[1:]}, rest, nil
 	case Int:
 		value, err := strconv.ParseInt(fst.Span, 0, 64)
+		if errors.Is(err, strconv.ErrRange) {
+			if n, ok := new(big.Int).SetString(fst.Span, 10); ok {
+				return ast.BigIntLiteral{Value: n}, rest, nil
+			}
+		}
 		if err != nil {
 			return nil, nil, err
 		}
 		return ast.IntLiteral{Value: value}, rest, nil
+	case Rational:
+		value, ok := new(big.Rat).SetString(fst.Span)
+		if !ok {
+			return nil, nil, errors.New("invalid rational " + fst.Span)
+		}
+		return ast.RationalLiteral{Value: value}, rest, nil
 	case Float:
 		value, err := strconv.ParseFloat(fst.Span, 64)
 		if err != nil {
@@ -45,12 +62,49 @@ func parse(tokens []Token) (ast.Expression, []Token, error) {
 		}
 		return ast.FloatLiteral{Value: value}, rest, nil
 	case String:
-		value := parseString(fst.Span)
-		return ast.StringLiteral{Value: value}, rest, nil
+		str, err := parseString(fst.Span)
+		if err != nil {
+			return nil, nil, err
+		}
+		return str, rest, nil
+
+	case Command:
+		cmd, err := parseCommand(fst.Span)
+		if err != nil {
+			return nil, nil, err
+		}
+		return cmd, rest, nil
+
+	case Regex:
+		// trim #/ /
+		pattern := strings.ReplaceAll(fst.Span[2:len(fst.Span)-1], "\\/", "/")
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, nil, err
+		}
+		return ast.RegexLiteral{Regexp: re}, rest, nil
 
 	case BracketOpen:
 		return parseArray(rest)
 
+	case CurlyOpen:
+		return parseMap(rest)
+
+	case Quote:
+		return parseQuote(rest, false)
+
+	case Quasiquote:
+		return parseQuote(rest, true)
+
+	case Unquote, UnquoteSplicing:
+		return nil, nil, errors.New(fst.Span + " is only allowed inside of a quasiquote")
+
+	case Embedded:
+		if code, ok := fst.Value.(*value.Code); ok {
+			return code.Expr, rest, nil
+		}
+		return ast.Constant{Value: fst.Value}, rest, nil
+
 	case EndOfInput:
 		return nil, nil, errors.New("unexpected end of input")
 	}
@@ -76,66 +130,43 @@ func parseBody(tokens []Token) (ast.Expression, []Token, error) {
 			return nil, nil, err
 		}
 
-		fields := make([]string, 0)
-		for i, t := range tokens {
-			if t.Tag == Identifier {
-				fields = append(fields, t.Span)
-				continue
-			}
-			if t.Tag == ParenClosing {
-				// advance token list
-				tokens = tokens[i+1:]
-				break
-			}
-			// illegal token encountered
-			return nil, nil, Expected{Candidates: "<ident> )"}
+		fields, tokens, err := parseArgs(tokens)
+		if err != nil {
+			return nil, nil, err
 		}
 
 		methods := make([]ast.FunctionDefinition, 0)
 		for tokens[0].Tag != ParenClosing {
 			_, tokens, err = expect(tokens, Fun, "fun")
 			if err != nil {
-				return nil, nil, Expected{Candidates: ")", or: err.(*Expected)}
+				or := err.(Expected)
+				return nil, nil, Expected{Candidates: ")", or: &or}
 			}
 
-			name, tokens, err = expect(tokens, Identifier, "<ident>")
+			var methodName Token
+			methodName, tokens, err = expect(tokens, Identifier, "<ident>")
 			if err != nil {
 				return nil, nil, err
 			}
 
-			_, tokens, err = expect(tokens, ParenOpen, "<ident>")
+			_, tokens, err = expect(tokens, ParenOpen, "(")
 			if err != nil {
 				return nil, nil, err
 			}
 
-			args := make([]string, 0)
-			for i, t := range tokens {
-				if t.Tag == Identifier {
-					args = append(args, t.Span)
-					continue
-				}
-				if t.Tag == ParenClosing {
-					tokens = tokens[i+1:]
-					break
-				}
-				return nil, nil, Expected{Candidates: "<ident> )"}
-			}
-
-			_, tokens, err = expect(tokens, ParenClosing, ")")
+			var signature ast.Signature
+			signature, tokens, err = parseSignature(tokens)
 			if err != nil {
 				return nil, nil, err
 			}
-			_, tokens, err = expect(tokens, ParenOpen, "(")
-			if err != nil {
-				return nil, nil, err
-			}
-			body, rest, err := parseExpr(tokens)
+
+			body, rest, err := parse(tokens)
 			tokens = rest
 			if err != nil {
 				return nil, nil, err
 			}
 
-			fd := ast.FunctionDefinition{Name: name.Span, Args: args, Body: body}
+			fd := ast.FunctionDefinition{Name: methodName.Span, Signature: signature, Body: body}
 			methods = append(methods, fd)
 		}
 
@@ -147,45 +178,170 @@ func parseBody(tokens []Token) (ast.Expression, []Token, error) {
 		return ast.ClassDefinition{Name: name.Span, Fields: fields, Methods: methods}, tokens, nil
 	}
 
-	// (fun)
-	if tokens[0].Tag == Fun && tokens[1].Tag == Identifier {
-		funcName := tokens[1].Span
+	// (fun name (args) body...)
+	// or without a name, as a lambda
+	// (fun (args) body...)
+	if tokens[0].Tag == Fun {
+		tokens = tokens[1:]
 
-		_, tokens, err := expect(tokens, ParenOpen, "(")
-		if err != nil {
-			return nil, nil, err
-		}
-		// TODO ident ) ( parseExpr
-		args := make([]string, 0)
-		for tokens[0].Tag != Identifier {
-			args = append(args, tokens[0].Span)
+		funcName := ""
+		if tokens[0].Tag == Identifier {
+			funcName = tokens[0].Span
 			tokens = tokens[1:]
-
-			if len(tokens) == 0 {
-				return nil, nil, Expected{Candidates: "<argument> )"}
-			}
 		}
 
-		_, tokens, err = expect(tokens, ParenClosing, ")")
+		_, tokens, err := expect(tokens, ParenOpen, "(")
 		if err != nil {
 			return nil, nil, err
 		}
-		_, tokens, err = expect(tokens, ParenOpen, "(")
+
+		signature, tokens, err := parseSignature(tokens)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		body, rest, err := parseExpr(tokens)
+		statements, rest, err := parseList(tokens, ParenClosing, ")")
 		if err != nil {
 			return nil, nil, err
 		}
 
-		return ast.FunctionDefinition{Name: funcName, Args: args, Body: body}, rest, nil
+		var body ast.Expression
+		switch len(statements) {
+		case 0:
+			return nil, nil, errors.New("function without a body")
+		case 1:
+			body = statements[0]
+		default:
+			body = ast.DoFlow{Statements: statements}
+		}
+
+		if funcName == "" {
+			return ast.LambdaLiteral{Signature: signature, Body: body}, rest, nil
+		}
+
+		return ast.FunctionDefinition{Name: funcName, Signature: signature, Body: body}, rest, nil
+	}
+
+	if tokens[0].Tag == Identifier {
+		switch tokens[0].Span {
+		// (quote x) or (quasiquote x)
+		case "quote", "quasiquote":
+			quote, rest, err := parseQuote(tokens[1:], tokens[0].Span == "quasiquote")
+			if err != nil {
+				return nil, nil, err
+			}
+
+			_, rest, err = expect(rest, ParenClosing, ")")
+			if err != nil {
+				return nil, nil, err
+			}
+			return quote, rest, nil
+
+		// (macro name (args) body...)
+		case "macro":
+			name, tokens, err := expect(tokens[1:], Identifier, "<macro name>")
+			if err != nil {
+				return nil, nil, err
+			}
+
+			_, tokens, err = expect(tokens, ParenOpen, "(")
+			if err != nil {
+				return nil, nil, err
+			}
+
+			signature, tokens, err := parseSignature(tokens)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			statements, rest, err := parseList(tokens, ParenClosing, ")")
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(statements) == 0 {
+				return nil, nil, errors.New("macro without a body")
+			}
+
+			return ast.MacroDefinition{Name: name.Span, Signature: signature, Body: implicitDo(statements)}, rest, nil
+		}
 	}
 
 	return parseExpr(tokens)
 }
 
+// parses the argument names of a function, after the opening paren
+// x y z)
+func parseArgs(tokens []Token) ([]string, []Token, error) {
+	args := make([]string, 0)
+	for i, t := range tokens {
+		if t.Tag == Identifier {
+			args = append(args, t.Span)
+			continue
+		}
+		if t.Tag == ParenClosing {
+			return args, tokens[i+1:], nil
+		}
+		break
+	}
+
+	return nil, nil, Expected{Candidates: "<ident> )"}
+}
+
+// parses the parameters of a function, after the opening paren
+// a (b default) ..rest)
+func parseSignature(tokens []Token) (ast.Signature, []Token, error) {
+	signature := ast.Signature{Args: make([]string, 0)}
+	expected := Expected{Candidates: "<ident> (<ident> <default>) ..<rest> )"}
+
+	for len(tokens) > 0 {
+		t := tokens[0]
+		switch {
+		case t.Tag == ParenClosing:
+			return signature, tokens[1:], nil
+
+		case t.Tag == Identifier && strings.HasPrefix(t.Span, ".."):
+			if len(t.Span) == 2 {
+				return signature, nil, errors.New("expected a name after ..")
+			}
+			signature.Rest = t.Span[2:]
+			if _, _, err := expect(tokens[1:], ParenClosing, ")"); err != nil {
+				return signature, nil, errors.New(t.Span + " has to be the last argument")
+			}
+			tokens = tokens[1:]
+
+		case t.Tag == Identifier:
+			signature.Args = append(signature.Args, t.Span)
+			signature.Defaults = append(signature.Defaults, nil)
+			tokens = tokens[1:]
+
+		// (b 10)
+		case t.Tag == ParenOpen:
+			name, rest, err := expect(tokens[1:], Identifier, "<ident>")
+			if err != nil {
+				return signature, nil, err
+			}
+
+			def, rest, err := parse(rest)
+			if err != nil {
+				return signature, nil, err
+			}
+
+			_, tokens, err = expect(rest, ParenClosing, ")")
+			if err != nil {
+				return signature, nil, err
+			}
+
+			signature.Args = append(signature.Args, name.Span)
+			signature.Defaults = append(signature.Defaults, def)
+
+		default:
+			return signature, nil, expected
+		}
+	}
+
+	return signature, nil, expected
+}
+
 func expect(tokens []Token, tag int, expected string) (Token, []Token, error) {
 	if len(tokens) == 0 ||
 		tokens[0].Tag != tag {
@@ -204,6 +360,27 @@ func parseArray(tokens []Token) (ast.Expression, []Token, error) {
 	return ast.ArrayLiteral{Values: expr}, rest, nil
 }
 
+// {key value ...}
+func parseMap(tokens []Token) (ast.Expression, []Token, error) {
+	expr, rest, err := parseList(tokens, CurlyClosing, "}")
+	if err != nil {
+		return nil, rest, err
+	}
+
+	if len(expr)%2 != 0 {
+		return nil, nil, errors.New("map literal needs an even number of elements, got " + strconv.Itoa(len(expr)))
+	}
+
+	keys := make([]ast.Expression, 0, len(expr)/2)
+	values := make([]ast.Expression, 0, len(expr)/2)
+	for i := 0; i < len(expr); i += 2 {
+		keys = append(keys, expr[i])
+		values = append(values, expr[i+1])
+	}
+
+	return ast.MapLiteral{Keys: keys, Values: values}, rest, nil
+}
+
 // (a b c)
 // or
 // (<3 a b c)
@@ -250,16 +427,71 @@ func parseExpr(tokens []Token) (ast.Expression, []Token, error) {
 		case "or":
 			return ast.OrFlow{Arguments: expr}, rest, nil
 		case "let":
-			if len(expr) != 3 {
-				return nil, rest, errors.New("expected precisely 3 arguments to let")
+			let, err := parseLet(expr)
+			return let, rest, err
+		case "while":
+			if len(expr) < 2 {
+				return nil, rest, errors.New("expected a condition and a body in while")
+			}
+			return ast.WhileLoop{Condition: expr[0], Body: implicitDo(expr[1:])}, rest, nil
+		case "for":
+			loop, err := parseFor(expr)
+			return loop, rest, err
+		case "loop":
+			loop, err := parseLoop(expr)
+			return loop, rest, err
+		case "import":
+			imp, err := parseImport(expr)
+			return imp, rest, err
+		case "try":
+			try, err := parseTry(expr)
+			return try, rest, err
+		case "with-env":
+			if len(expr) < 2 {
+				return nil, rest, errors.New("expected variables and a body in with-env")
+			}
+			return ast.WithEnv{Vars: expr[0], Body: implicitDo(expr[1:])}, rest, nil
+		case "pipe":
+			if len(expr) == 0 {
+				return nil, rest, errors.New("expected at least 1 command in pipe")
+			}
+			return ast.Pipe{Stages: expr}, rest, nil
+		// (> 2 1) compares, (> "file" `ls`) redirects
+		case ">", ">>", "<":
+			if len(expr) == 2 && ast.IsCommand(expr[1]) {
+				return ast.Redirect{Op: ty, File: expr[0], Command: expr[1]}, rest, nil
+			}
+		case "recur":
+			return ast.Recur{Arguments: expr}, rest, nil
+		case "break":
+			switch len(expr) {
+			case 0:
+				return ast.Break{}, rest, nil
+			case 1:
+				return ast.Break{Value: expr[0]}, rest, nil
+			}
+			return nil, rest, errors.New("expected at most 1 argument to break")
+		case "continue":
+			if len(expr) != 0 {
+				return nil, rest, errors.New("continue doesn't take arguments")
+			}
+			return ast.Continue{}, rest, nil
+		case "def", "var", "set!":
+			if len(expr) != 2 {
+				return nil, rest, errors.New("expected precisely 2 arguments to " + ty)
+			}
+			ident, ok := expr[0].(ast.IdentLiteral)
+			if !ok {
+				return nil, rest, Expected{Candidates: "<ident>"}
 			}
-			if ident, ok := expr[0].(ast.IdentLiteral); ok {
-				val := expr[1]
-				body := expr[2]
 
-				return ast.VariableDefiniton{Ident: ident.Value, Value: val, Body: body}, rest, nil
+			switch ty {
+			case "def":
+				return ast.GlobalDefinition{Ident: ident.Value, Value: expr[1]}, rest, nil
+			case "var":
+				return ast.VarDeclaration{Ident: ident.Value, Value: expr[1]}, rest, nil
 			}
-			return nil, rest, Expected{Candidates: "<ident>"}
+			return ast.Assignment{Ident: ident.Value, Value: expr[1]}, rest, nil
 		}
 
 		return ast.NamedCall{Function: ty, Arguments: expr}, rest, nil
@@ -268,6 +500,257 @@ func parseExpr(tokens []Token) (ast.Expression, []Token, error) {
 	return ast.Call{Function: fst, Arguments: expr}, rest, nil
 }
 
+// parseLet parses the arguments of one of
+//
+//	(let x value body...)
+//	(let [x y ..rest] value body...)
+//	(let (a 1 b (+ a 1) (Point x y) p) body...)
+func parseLet(args []ast.Expression) (ast.Expression, error) {
+	if len(args) == 0 {
+		return nil, Expected{Candidates: "<ident> <bindings>"}
+	}
+
+	var bindings []ast.Expression
+	var body []ast.Expression
+	switch args[0].(type) {
+	case ast.IdentLiteral, ast.ArrayLiteral:
+		if len(args) < 3 {
+			return nil, errors.New("expected a pattern, a value and a body in let")
+		}
+		bindings, body = args[:2], args[2:]
+	default:
+		var ok bool
+		if bindings, ok = unwrapList(args[0]); !ok {
+			return nil, Expected{Candidates: "<ident> <bindings>"}
+		}
+		body = args[1:]
+	}
+
+	if len(body) == 0 {
+		return nil, errors.New("let without a body")
+	}
+	bodyExpr := implicitDo(body)
+
+	// the simple form stays as it was
+	if ident, ok := bindings[0].(ast.IdentLiteral); ok && len(bindings) == 2 && !strings.HasPrefix(ident.Value, "..") {
+		return ast.VariableDefiniton{Ident: ident.Value, Value: bindings[1], Body: bodyExpr}, nil
+	}
+
+	parsed, err := parseBindings(bindings)
+	if err != nil {
+		return nil, err
+	}
+	return ast.Let{Bindings: parsed, Body: bodyExpr}, nil
+}
+
+// parseBindings expects alternating patterns and values
+func parseBindings(exprs []ast.Expression) ([]ast.Binding, error) {
+	if len(exprs)%2 != 0 {
+		return nil, errors.New("expected pairs of patterns and values")
+	}
+
+	bindings := make([]ast.Binding, 0, len(exprs)/2)
+	for i := 0; i < len(exprs); i += 2 {
+		p, err := parsePattern(exprs[i])
+		if err != nil {
+			return nil, err
+		}
+		bindings = append(bindings, ast.Binding{Pattern: p, Value: exprs[i+1]})
+	}
+	return bindings, nil
+}
+
+// unwrapList returns the elements of a list like (a 1 b 2), which was already parsed as a call
+func unwrapList(expr ast.Expression) ([]ast.Expression, bool) {
+	switch expr := expr.(type) {
+	case ast.NamedCall:
+		return append([]ast.Expression{ast.IdentLiteral{Value: expr.Function}}, expr.Arguments...), true
+	case ast.Call:
+		return append([]ast.Expression{expr.Function}, expr.Arguments...), true
+	}
+	return nil, false
+}
+
+// implicitDo wraps multiple expressions in a do block
+func implicitDo(body []ast.Expression) ast.Expression {
+	if len(body) == 1 {
+		return body[0]
+	}
+	return ast.DoFlow{Statements: body}
+}
+
+// parseImport parses the arguments of one of
+//
+//	(import "path")
+//	(import "path" as m)
+//	(import (only foo bar) from "path")
+func parseImport(args []ast.Expression) (ast.Expression, error) {
+	expected := Expected{Candidates: "\"<path>\" \"<path>\" as <ident> (only <ident>...) from \"<path>\""}
+
+	if len(args) == 1 {
+		if path, ok := args[0].(ast.StringLiteral); ok {
+			return ast.Import{Path: path.Value}, nil
+		}
+		return nil, expected
+	}
+
+	if len(args) != 3 {
+		return nil, expected
+	}
+
+	keyword, ok := args[1].(ast.IdentLiteral)
+	if !ok {
+		return nil, expected
+	}
+
+	if path, ok := args[0].(ast.StringLiteral); ok && keyword.Value == "as" {
+		alias, ok := args[2].(ast.IdentLiteral)
+		if !ok {
+			return nil, Expected{Candidates: "<ident>"}
+		}
+		return ast.Import{Path: path.Value, Alias: alias.Value}, nil
+	}
+
+	path, ok := args[2].(ast.StringLiteral)
+	if !ok || keyword.Value != "from" {
+		return nil, expected
+	}
+
+	list, ok := unwrapList(args[0])
+	if !ok {
+		return nil, expected
+	}
+	if only, ok := list[0].(ast.IdentLiteral); !ok || only.Value != "only" {
+		return nil, expected
+	}
+
+	imp := ast.Import{Path: path.Value, Only: make([]string, 0, len(list)-1)}
+	for _, name := range list[1:] {
+		ident, ok := name.(ast.IdentLiteral)
+		if !ok {
+			return nil, Expected{Candidates: "<ident>"}
+		}
+		imp.Only = append(imp.Only, ident.Value)
+	}
+	return imp, nil
+}
+
+// (try body... (catch e handler...))
+func parseTry(args []ast.Expression) (ast.Expression, error) {
+	expected := Expected{Candidates: "(catch <ident> <expr>...)"}
+	if len(args) < 2 {
+		return nil, expected
+	}
+
+	catch, ok := unwrapList(args[len(args)-1])
+	if !ok || len(catch) < 3 {
+		return nil, expected
+	}
+	if head, ok := catch[0].(ast.IdentLiteral); !ok || head.Value != "catch" {
+		return nil, expected
+	}
+	name, ok := catch[1].(ast.IdentLiteral)
+	if !ok {
+		return nil, Expected{Candidates: "<ident>"}
+	}
+
+	return ast.Try{
+		Body:    implicitDo(args[:len(args)-1]),
+		Name:    name.Value,
+		Handler: implicitDo(catch[2:]),
+	}, nil
+}
+
+// (for (x in coll) body...)
+func parseFor(args []ast.Expression) (ast.Expression, error) {
+	if len(args) == 0 {
+		return nil, Expected{Candidates: "(<pattern> in <collection>)"}
+	}
+
+	head, ok := unwrapList(args[0])
+	if !ok || len(head) != 3 {
+		return nil, Expected{Candidates: "(<pattern> in <collection>)"}
+	}
+	if in, ok := head[1].(ast.IdentLiteral); !ok || in.Value != "in" {
+		return nil, Expected{Candidates: "(<pattern> in <collection>)"}
+	}
+	if len(args) < 2 {
+		return nil, errors.New("for without a body")
+	}
+
+	p, err := parsePattern(head[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.ForLoop{Pattern: p, Collection: head[2], Body: implicitDo(args[1:])}, nil
+}
+
+// (loop (i 0 acc 1) body...)
+func parseLoop(args []ast.Expression) (ast.Expression, error) {
+	if len(args) < 2 {
+		return nil, errors.New("expected bindings and a body in loop")
+	}
+
+	list, ok := unwrapList(args[0])
+	if !ok {
+		return nil, Expected{Candidates: "<bindings>"}
+	}
+
+	bindings, err := parseBindings(list)
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.Loop{Bindings: bindings, Body: implicitDo(args[1:])}, nil
+}
+
+// parsePattern turns the already parsed expression on the left side of a let binding into a pattern
+func parsePattern(expr ast.Expression) (ast.Expression, error) {
+	switch expr := expr.(type) {
+	case ast.IdentLiteral:
+		if strings.HasPrefix(expr.Value, "..") {
+			return nil, errors.New(expr.Value + " is only allowed at the end of an array pattern")
+		}
+		return ast.IdentPattern{Ident: expr.Value}, nil
+
+	case ast.ArrayLiteral:
+		p := ast.ArrayPattern{}
+		elements := expr.Values
+		if len(elements) > 0 {
+			if rest, ok := elements[len(elements)-1].(ast.IdentLiteral); ok && strings.HasPrefix(rest.Value, "..") {
+				if rest.Value == ".." {
+					return nil, errors.New("expected a name after .. in array pattern")
+				}
+				p.Rest = rest.Value[2:]
+				elements = elements[:len(elements)-1]
+			}
+		}
+
+		for _, e := range elements {
+			element, err := parsePattern(e)
+			if err != nil {
+				return nil, err
+			}
+			p.Elements = append(p.Elements, element)
+		}
+		return p, nil
+
+	case ast.NamedCall:
+		p := ast.ClassPattern{Class: expr.Function}
+		for _, f := range expr.Arguments {
+			field, err := parsePattern(f)
+			if err != nil {
+				return nil, err
+			}
+			p.Fields = append(p.Fields, field)
+		}
+		return p, nil
+	}
+
+	return nil, Expected{Candidates: "<ident> [<pattern>...] (<class> <pattern>...)"}
+}
+
 func parseList(tokens []Token, closingTag int, expectedClosing string) ([]ast.Expression, []Token, error) {
 	exprs := make([]ast.Expression, 0)
 
@@ -288,41 +771,223 @@ func parseList(tokens []Token, closingTag int, expectedClosing string) ([]ast.Ex
 	return exprs, tokens[1:], nil
 }
 
-func parseString(raw string) string {
-	// trim " " chars.
-	raw = raw[1:]
-	raw = raw[:len(raw)-1]
-
-	buffer := ""
-
-	escaped := true
-	for _, c := range raw {
-		if escaped {
-			switch c {
-			case 'n':
-				buffer += "\n"
-			case 't':
-				buffer += "\t"
-			case '\\':
-				buffer += "\\"
-			case 'r':
-				buffer += "\r"
-			default:
-				buffer += string(c)
-			}
-			escaped = false
+// parseString turns the raw token into a StringLiteral,
+// or an InterpolatedString if it contains ${...}
+func parseString(raw string) (ast.Expression, error) {
+	isRaw := strings.HasPrefix(raw, "r")
+	if isRaw {
+		raw = raw[1:]
+	}
+
+	delim := "\""
+	if strings.HasPrefix(raw, "\"\"\"") && len(raw) >= 6 {
+		delim = "\"\"\""
+	}
+
+	// trim delimiters
+	raw = raw[len(delim) : len(raw)-len(delim)]
+
+	if delim == "\"\"\"" {
+		raw = dedent(raw)
+	}
+
+	if isRaw {
+		return ast.StringLiteral{Value: raw}, nil
+	}
+
+	parts := make([]ast.Expression, 0)
+	interpolated := false
+
+	literal := ""
+	for i := 0; i < len(raw); i++ {
+		switch {
+		case raw[i] == '\\':
+			// escapes are decoded by unescape
+			_, n, err := decodeEscape(raw[i:])
+			if err != nil {
+				return nil, err
+			}
+			literal += raw[i : i+n]
+			i += n - 1
+			continue
+		case strings.HasPrefix(raw[i:], "${"):
+			n, err := takeInterpolation(raw[i+2:])
+			if err != nil {
+				return nil, err
+			}
+
+			// without the closing }
+			expr, err := parseInterpolation(raw[i+2 : i+1+n])
+			if err != nil {
+				return nil, err
+			}
+
+			if literal != "" {
+				str, err := unescape(literal)
+				if err != nil {
+					return nil, err
+				}
+				parts = append(parts, ast.StringLiteral{Value: str})
+				literal = ""
+			}
+			parts = append(parts, expr)
+			interpolated = true
+
+			i += 1 + n
+			continue
+		}
+
+		literal += raw[i : i+1]
+	}
+
+	str, err := unescape(literal)
+	if err != nil {
+		return nil, err
+	}
+
+	if !interpolated {
+		return ast.StringLiteral{Value: str}, nil
+	}
+
+	if str != "" {
+		parts = append(parts, ast.StringLiteral{Value: str})
+	}
+	return ast.InterpolatedString{Parts: parts}, nil
+}
+
+// parseCommand splits `...` into arguments like a shell does.
+// Arguments are separated by whitespace, '...' is taken literally and "..." is a string.
+// ${x} is inserted as a single argument, even if it contains spaces.
+func parseCommand(raw string) (ast.Expression, error) {
+	// trim backticks
+	raw = raw[1 : len(raw)-1]
+
+	args := make([]ast.Expression, 0)
+	// every argument is collected as the content of a string literal
+	word, inWord := "", false
+	end := func() error {
+		if !inWord {
+			return nil
+		}
+		arg, err := parseString("\"" + word + "\"")
+		if err != nil {
+			return err
+		}
+		args = append(args, arg)
+		word, inWord = "", false
+		return nil
+	}
+
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if err := end(); err != nil {
+				return nil, err
+			}
 			continue
+
+		case c == '\\' && i+1 < len(raw):
+			word += escapeLiteral(raw[i+1 : i+2])
+			i++
+
+		case c == '\'':
+			n := strings.IndexByte(raw[i+1:], '\'')
+			if n < 0 {
+				return nil, errors.New("unterminated ' in command")
+			}
+			word += escapeLiteral(raw[i+1 : i+1+n])
+			i += 1 + n
+
+		case c == '"':
+			n := 1
+			for ; i+n < len(raw) && raw[i+n] != '"'; n++ {
+				if raw[i+n] == '\\' {
+					n++
+				} else if strings.HasPrefix(raw[i+n:], "${") {
+					m, err := takeInterpolation(raw[i+n+2:])
+					if err != nil {
+						return nil, err
+					}
+					n += 1 + m
+				}
+			}
+			if i+n >= len(raw) {
+				return nil, errors.New("unterminated \" in command")
+			}
+			word += raw[i+1 : i+n]
+			i += n
+
+		case strings.HasPrefix(raw[i:], "${"):
+			n, err := takeInterpolation(raw[i+2:])
+			if err != nil {
+				return nil, err
+			}
+			word += raw[i : i+2+n]
+			i += 1 + n
+
+		default:
+			word += escapeLiteral(raw[i : i+1])
 		}
+		inWord = true
+	}
 
-		if c == '\\' {
-			escaped = true
+	if err := end(); err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, errors.New("empty command")
+	}
+	return ast.Command{Arguments: args}, nil
+}
+
+// escapeLiteral escapes s for the content of a string literal
+func escapeLiteral(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "\"", "\\\"")
+	return strings.ReplaceAll(s, "$", "\\$")
+}
+
+// parseInterpolation parses the single expression inside of ${...}
+func parseInterpolation(code string) (ast.Expression, error) {
+	tokens, err := Tokenize(code)
+	if err != nil {
+		return nil, err
+	}
+
+	expr, rest, err := Parse(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) > 0 {
+		return nil, errors.New("expected a single expression in ${" + code + "}")
+	}
+
+	return expr, nil
+}
+
+// unescape decodes all escape sequences in raw, see decodeEscape
+func unescape(raw string) (string, error) {
+	var buffer strings.Builder
+
+	for i := 0; i < len(raw); {
+		if raw[i] != '\\' {
+			buffer.WriteByte(raw[i])
+			i++
 			continue
 		}
 
-		buffer += string(c)
+		decoded, n, err := decodeEscape(raw[i:])
+		if err != nil {
+			return "", err
+		}
+
+		buffer.WriteString(decoded)
+		i += n
 	}
 
-	return buffer
+	return buffer.String(), nil
 }
 
 type Expected struct {
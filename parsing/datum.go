@@ -0,0 +1,287 @@
+package parsing
+
+import (
+	"errors"
+	"interpreter/ast"
+	"interpreter/value"
+	"strconv"
+)
+
+// Read reads the next form as data instead of parsing it as an expression.
+// Lists become value.List, names value.Symbol, :names value.Keyword and literals their values.
+// 'x is read as (quote x), ~x as (quasiquote x), ,x as (unquote x) and ,@x as (unquote-splicing x).
+func Read(tokens []Token) (value.Object, []Token, error) {
+	ts := make([]Token, 0)
+
+	for _, t := range tokens {
+		if t.Tag == Whitespace {
+			continue
+		}
+		ts = append(ts, t)
+	}
+
+	return read(ts)
+}
+
+func read(tokens []Token) (value.Object, []Token, error) {
+	if len(tokens) == 0 {
+		return nil, tokens, Expected{Candidates: "<expr>"}
+	}
+
+	fst := tokens[0]
+	rest := tokens[1:]
+	switch fst.Tag {
+	case ParenOpen:
+		values, rest, err := readList(rest, ParenClosing, ")")
+		if err != nil {
+			return nil, nil, err
+		}
+		return value.NewList(values), rest, nil
+
+	case BracketOpen:
+		values, rest, err := readList(rest, BracketClosing, "]")
+		if err != nil {
+			return nil, nil, err
+		}
+		return value.NewArrayOf(values), rest, nil
+
+	case CurlyOpen:
+		values, rest, err := readList(rest, CurlyClosing, "}")
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(values)%2 != 0 {
+			return nil, nil, errors.New("map literal needs an even number of elements, got " + strconv.Itoa(len(values)))
+		}
+
+		m := value.NewMap()
+		for i := 0; i < len(values); i += 2 {
+			if err := m.Set(values[i], values[i+1]); err != nil {
+				return nil, nil, err
+			}
+		}
+		return m, rest, nil
+
+	case Quote:
+		return readPrefixed("quote", rest)
+	case Quasiquote:
+		return readPrefixed("quasiquote", rest)
+	case Unquote:
+		return readPrefixed("unquote", rest)
+	case UnquoteSplicing:
+		return readPrefixed("unquote-splicing", rest)
+
+	case Identifier, Heart, Class, Fun:
+		return value.NewSymbol(fst.Span), rest, nil
+
+	case Keyword:
+		return value.NewKeyword(fst.Span[1:]), rest, nil
+
+	case Embedded:
+		return fst.Value.(value.Object), rest, nil
+
+	case Int, Rational, Float, String, Regex, Command:
+		expr, _, err := parse(tokens[:1])
+		if err != nil {
+			return nil, nil, err
+		}
+		return literal(expr, fst.Span), rest, nil
+
+	case EndOfInput:
+		return nil, nil, errors.New("unexpected end of input")
+	}
+
+	return nil, nil, Expected{Candidates: "<expr>"}
+}
+
+// readPrefixed reads 'x as (quote x), ~x as (quasiquote x) and so on
+func readPrefixed(name string, tokens []Token) (value.Object, []Token, error) {
+	datum, rest, err := read(tokens)
+	if err != nil {
+		return nil, nil, err
+	}
+	return value.NewList([]value.Object{value.NewSymbol(name), datum}), rest, nil
+}
+
+func readList(tokens []Token, closingTag int, expectedClosing string) ([]value.Object, []Token, error) {
+	values := make([]value.Object, 0)
+
+	for len(tokens) > 0 && tokens[0].Tag != closingTag {
+		datum, rest, err := read(tokens)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		values = append(values, datum)
+		tokens = rest
+	}
+
+	if len(tokens) == 0 {
+		return nil, nil, Expected{Candidates: "<expr> " + expectedClosing}
+	}
+
+	return values, tokens[1:], nil
+}
+
+// literal returns the value of a parsed literal.
+// Interpolated strings contain code, so they are kept as value.Code.
+func literal(expr ast.Expression, source string) value.Object {
+	switch expr := expr.(type) {
+	case ast.IntLiteral:
+		return value.NewInt(expr.Value)
+	case ast.BigIntLiteral:
+		return value.NewBigInt(expr.Value)
+	case ast.RationalLiteral:
+		return value.NewRational(expr.Value)
+	case ast.FloatLiteral:
+		return value.NewFloat(expr.Value)
+	case ast.StringLiteral:
+		return value.NewString(expr.Value)
+	case ast.RegexLiteral:
+		return value.FromRegexp(expr.Regexp)
+	}
+
+	return &value.Code{Expr: expr, Source: source}
+}
+
+// FromDatum parses code given as data, e.g. the expansion of a macro
+func FromDatum(datum value.Object) (ast.Expression, error) {
+	expr, rest, err := parse(unread(datum, nil))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) > 0 {
+		return nil, errors.New("expected a single expression in " + datum.Str())
+	}
+
+	return expr, nil
+}
+
+// unread turns data back into the tokens it would have been read from.
+// Values without a literal, e.g. functions, are passed on as Embedded tokens.
+func unread(datum value.Object, tokens []Token) []Token {
+	switch d := datum.(type) {
+	case *value.List:
+		tokens = append(tokens, Token{Tag: ParenOpen, Span: "("})
+		for i := 0; i < d.Len(); i++ {
+			tokens = unread(d.At(i), tokens)
+		}
+		return append(tokens, Token{Tag: ParenClosing, Span: ")"})
+
+	case *value.Array:
+		tokens = append(tokens, Token{Tag: BracketOpen, Span: "["})
+		for i := 0; i < d.Len(); i++ {
+			tokens = unread(d.At(i), tokens)
+		}
+		return append(tokens, Token{Tag: BracketClosing, Span: "]"})
+
+	case *value.Map:
+		tokens = append(tokens, Token{Tag: CurlyOpen, Span: "{"})
+		values := d.Values()
+		for i, k := range d.Keys() {
+			tokens = unread(values[i], unread(k, tokens))
+		}
+		return append(tokens, Token{Tag: CurlyClosing, Span: "}"})
+
+	case *value.Symbol:
+		return append(tokens, symbolToken(d.Name()))
+
+	case *value.Keyword:
+		return append(tokens, Token{Tag: Keyword, Span: d.Str()})
+	}
+
+	return append(tokens, Token{Tag: Embedded, Span: datum.Str(), Value: datum})
+}
+
+// symbolToken is the token a symbol was read from
+func symbolToken(name string) Token {
+	switch name {
+	case "class":
+		return Token{Tag: Class, Span: name}
+	case "fun":
+		return Token{Tag: Fun, Span: name}
+	case "<3":
+		return Token{Tag: Heart, Span: name}
+	}
+
+	return Token{Tag: Identifier, Span: name}
+}
+
+// unquote replaces ,x and ,@x in quasiquoted data with value.Unquote, holding the parsed expression
+func unquote(datum value.Object) (value.Object, error) {
+	switch d := datum.(type) {
+	case *value.List:
+		switch d.Head() {
+		case "unquote", "unquote-splicing":
+			if d.Len() != 2 {
+				return nil, errors.New("expected precisely 1 argument to " + d.Head())
+			}
+
+			expr, err := FromDatum(d.At(1))
+			if err != nil {
+				return nil, err
+			}
+			return &value.Unquote{Expr: expr, Splice: d.Head() == "unquote-splicing"}, nil
+		}
+
+		values, err := unquoteAll(value.Elements(d))
+		if err != nil {
+			return nil, err
+		}
+		return value.NewList(values), nil
+
+	case *value.Array:
+		values, err := unquoteAll(d.Values())
+		if err != nil {
+			return nil, err
+		}
+		return value.NewArrayOf(values), nil
+
+	case *value.Map:
+		keys, err := unquoteAll(d.Keys())
+		if err != nil {
+			return nil, err
+		}
+		values, err := unquoteAll(d.Values())
+		if err != nil {
+			return nil, err
+		}
+
+		m := value.NewMap()
+		for i := range keys {
+			if err := m.Set(keys[i], values[i]); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+
+	return datum, nil
+}
+
+func unquoteAll(values []value.Object) ([]value.Object, error) {
+	for i, v := range values {
+		u, err := unquote(v)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = u
+	}
+	return values, nil
+}
+
+// parseQuote parses the rest of (quote x) or 'x, and with quasi of (quasiquote x) or ~x
+func parseQuote(tokens []Token, quasi bool) (ast.Expression, []Token, error) {
+	datum, rest, err := read(tokens)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if quasi {
+		if datum, err = unquote(datum); err != nil {
+			return nil, nil, err
+		}
+	}
+	return ast.Quote{Datum: datum, Quasi: quasi}, rest, nil
+}
@@ -1,29 +1,44 @@
 package parsing
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 const (
 	Identifier = iota
 
 	Int
 	Float
+	Rational
 	String
+	Regex
+	Keyword
+	// `git status`
+	Command
 
 	Heart
 	Class
 	Fun
 
+	// 'x ~x ,x ,@x
+	Quote
+	Quasiquote
+	Unquote
+	UnquoteSplicing
+
+	// a value inserted by a macro, see Token.Value
+	Embedded
+
 	ParenOpen
 	ParenClosing
 	BracketOpen
 	BracketClosing
+	CurlyOpen
+	CurlyClosing
 	Whitespace
 
 	EndOfInput
-
-	// not yet used
-	CurlyOpen
-	CurlyClosing
 )
 
 func tagToStr(tag int) string {
@@ -34,14 +49,32 @@ func tagToStr(tag int) string {
 		return "Int"
 	case Float:
 		return "Float"
+	case Rational:
+		return "Rational"
 	case String:
 		return "String"
+	case Regex:
+		return "Regex"
+	case Keyword:
+		return "Keyword"
+	case Command:
+		return "Command"
 	case Heart:
 		return "Heart"
 	case Class:
 		return "Class"
 	case Fun:
 		return "Fun"
+	case Quote:
+		return "Quote"
+	case Quasiquote:
+		return "Quasiquote"
+	case Unquote:
+		return "Unquote"
+	case UnquoteSplicing:
+		return "UnquoteSplicing"
+	case Embedded:
+		return "Embedded"
 	case ParenOpen:
 		return "ParenOpen"
 	case ParenClosing:
@@ -50,14 +83,14 @@ func tagToStr(tag int) string {
 		return "BracketOpen"
 	case BracketClosing:
 		return "BracketClosing"
-	case Whitespace:
-		return "Whitespace"
-	case EndOfInput:
-		return "EndOfInput"
 	case CurlyOpen:
 		return "CurlyOpen"
 	case CurlyClosing:
 		return "CurlyClosing"
+	case Whitespace:
+		return "Whitespace"
+	case EndOfInput:
+		return "EndOfInput"
 	}
 
 	return "undefined"
@@ -66,6 +99,8 @@ func tagToStr(tag int) string {
 type Token struct {
 	Tag  int
 	Span string
+	// the value.Object of an Embedded token
+	Value interface{}
 }
 
 func (t Token) String() string {
@@ -91,6 +126,15 @@ func NextToken(input string) (Token, string, error) {
 		return Token{Tag: CurlyOpen, Span: fst}, rest, nil
 	case "}":
 		return Token{Tag: CurlyClosing, Span: fst}, rest, nil
+	case "'":
+		return Token{Tag: Quote, Span: fst}, rest, nil
+	case "~":
+		return Token{Tag: Quasiquote, Span: fst}, rest, nil
+	case ",":
+		if strings.HasPrefix(rest, "@") {
+			return Token{Tag: UnquoteSplicing, Span: ",@"}, rest[1:], nil
+		}
+		return Token{Tag: Unquote, Span: fst}, rest, nil
 	}
 
 	// check if we have whitespace
@@ -98,19 +142,38 @@ func NextToken(input string) (Token, string, error) {
 		return Token{Tag: Whitespace, Span: ws}, rest, nil
 	}
 
-	if num, floating, rest := takeNumber(input); num != "" {
-		tag := Int
-		if floating {
-			tag = Float
-		}
-
+	if num, tag, rest := takeNumber(input); num != "" {
 		return Token{Tag: tag, Span: num}, rest, nil
 	}
 
-	if str, rest := takeString(input); str != "" {
+	str, rest, err := takeString(input)
+	if err != nil {
+		return Token{}, "", err
+	}
+	if str != "" {
 		return Token{Tag: String, Span: str}, rest, nil
 	}
 
+	re, rest, err := takeRegex(input)
+	if err != nil {
+		return Token{}, "", err
+	}
+	if re != "" {
+		return Token{Tag: Regex, Span: re}, rest, nil
+	}
+
+	cmd, rest, err := takeCommand(input)
+	if err != nil {
+		return Token{}, "", err
+	}
+	if cmd != "" {
+		return Token{Tag: Command, Span: cmd}, rest, nil
+	}
+
+	if kw, rest := takeKeyword(input); kw != "" {
+		return Token{Tag: Keyword, Span: kw}, rest, nil
+	}
+
 	// must be an identifier
 	ident, rest := takeIdent(input)
 	tag := Identifier
@@ -128,6 +191,12 @@ func NextToken(input string) (Token, string, error) {
 func Tokenize(input string) ([]Token, error) {
 	tokens := make([]Token, 0)
 
+	// #!/usr/bin/env interpreter in the first line of a script is ignored like a comment
+	if shebang, rest := takeShebang(input); shebang != "" {
+		tokens = append(tokens, Token{Tag: Whitespace, Span: shebang})
+		input = rest
+	}
+
 	for {
 		t, rest, err := NextToken(input)
 		if err != nil {
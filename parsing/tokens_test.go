@@ -40,6 +40,19 @@ func TestTokenization(t *testing.T) {
 		{"((a b) c)", "(,(,a, ,b,), ,c,)"},
 		{"(print \"hello\")", "(,print, ,\"hello\",)"},
 		{"(print \"\")", "(,print, ,\"\",)"},
+		{"(- -1 2)", "(,-, ,-1, ,2,)"},
+		{"(/ 1/3 -2/3 4)", "(,/, ,1/3, ,-2/3, ,4,)"},
+		{"[x ..rest]", "[,x, ,..rest,]"},
+		{"(f 1 :b 2)", "(,f, ,1, ,:b, ,2,)"},
+		{"'(a b)", "',(,a, ,b,)"},
+		{"~(a b)", "~,(,a, ,b,)"},
+		{"(out `git log -n ${n}`)", "(,out, ,`git log -n ${n}`,)"},
+		{"#!/usr/bin/env interpreter\n(a)", "#!/usr/bin/env interpreter,\n,(,a,)"},
+		{"(a ; comment\n b)", "(,a, ; comment\n ,b,)"},
+		{"(a) ; at the end", "(,a,), ; at the end"},
+		{"(a \"b;c\")", "(,a, ,\"b;c\",)"},
+		{"(match? s #/a\\/[b ]+/)", "(,match?, ,s, ,#/a\\/[b ]+/,)"},
+		{"(print \"a ${(str \"}\" x)} b\")", "(,print, ,\"a ${(str \"}\" x)} b\",)"},
 	}
 
 	for _, c := range cases {
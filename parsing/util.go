@@ -1,8 +1,11 @@
 package parsing
 
 import (
+	"errors"
+	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 // quickly stolen from https://stackoverflow.com/a/41604514
@@ -17,9 +20,33 @@ func splitAt(s string, n int) (string, string) {
 	return s, ""
 }
 
+// takeShebang takes the line #!... at the start of a script, without the line ending
+func takeShebang(s string) (string, string) {
+	if !strings.HasPrefix(s, "#!") {
+		return "", s
+	}
+
+	end := strings.IndexByte(s, '\n')
+	if end < 0 {
+		return s, ""
+	}
+	return s[:end], s[end:]
+}
+
+// takeWhitespace takes spaces and comments, which go from ; to the end of the line
 func takeWhitespace(s string) (string, string) {
-	for i, c := range s {
-		if !unicode.IsSpace(c) {
+	for i := 0; i < len(s); {
+		c, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case c == ';':
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				return s, ""
+			}
+			i += end
+		case unicode.IsSpace(c):
+			i += size
+		default:
 			return s[:i], s[i:]
 		}
 	}
@@ -29,7 +56,7 @@ func takeWhitespace(s string) (string, string) {
 
 func isSpecial(c rune) bool {
 	switch c {
-	case '(', ')', '[', ']', '{', '}', '"', '\'':
+	case '(', ')', '[', ']', '{', '}', '"', '\'', '~', ',', ';', '`':
 		return true
 	}
 
@@ -46,6 +73,19 @@ func takeIdent(s string) (string, string) {
 	return s, ""
 }
 
+// takeKeyword takes a colon followed by an identifier, e.g. :name
+func takeKeyword(s string) (string, string) {
+	if !strings.HasPrefix(s, ":") {
+		return "", s
+	}
+
+	ident, rest := takeIdent(s[1:])
+	if ident == "" {
+		return "", s
+	}
+	return ":" + ident, rest
+}
+
 func takeInt(s string) (string, string) {
 	for i, c := range s {
 		if !unicode.IsDigit(c) {
@@ -56,11 +96,29 @@ func takeInt(s string) (string, string) {
 	return s, ""
 }
 
-func takeNumber(s string) (string, bool, string) {
+// takeNumber returns the number and its tag, which is Int, Float or Rational (e.g. 1/3)
+func takeNumber(s string) (string, int, string) {
+	sign := ""
+	// a minus followed by a digit is a negative number, otherwise it's an identifier like -
+	if len(s) > 1 && s[0] == '-' && unicode.IsDigit(rune(s[1])) {
+		sign, s = "-", s[1:]
+	}
+
 	n, rest := takeInt(s)
+	if n == "" {
+		// e.g. ..rest is an identifier
+		return "", Int, s
+	}
+	n = sign + n
+
+	// the denominator has to follow the slash directly
+	if len(rest) > 1 && rest[0] == '/' && unicode.IsDigit(rune(rest[1])) {
+		den, rest := takeInt(rest[1:])
+		return n + "/" + den, Rational, rest
+	}
 
 	if !strings.HasPrefix(rest, ".") {
-		return n, false, rest
+		return n, Int, rest
 	}
 
 	n += "."
@@ -70,7 +128,7 @@ func takeNumber(s string) (string, bool, string) {
 
 	n += dec
 
-	return n, true, rest
+	return n, Float, rest
 
 	// TODO implement scientific notation parsing
 	/*
@@ -83,26 +141,209 @@ func takeNumber(s string) (string, bool, string) {
 	*/
 }
 
-func takeString(s string) (string, string) {
-	if !strings.HasPrefix(s, "\"") {
-		return "", s
+// takeString takes a string literal, one of
+//
+//	"..."
+//	"""..."""     spanning multiple lines
+//	r"..."        raw, without escapes and interpolation
+//	r"""..."""
+func takeString(s string) (string, string, error) {
+	raw := strings.HasPrefix(s, "r\"")
+	body := s
+	if raw {
+		body = s[1:]
 	}
 
-	escaped := false
-	for i, c := range s[1:] {
-		if c == '"' && !escaped {
-			// increment to respect leading "
-			// and in order to contain " symbol
-			i += 2
-			return s[:i], s[i:]
+	if !strings.HasPrefix(body, "\"") {
+		return "", s, nil
+	}
+
+	delim := "\""
+	if strings.HasPrefix(body, "\"\"\"") {
+		delim = "\"\"\""
+	}
+
+	// delimiters are ascii, so it's fine to walk bytes instead of runes
+	for i := len(s) - len(body) + len(delim); i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], delim):
+			// include the closing delimiter
+			end := i + len(delim)
+			return s[:end], s[end:], nil
+		case raw:
+			continue
+		case s[i] == '\\':
+			_, n, err := decodeEscape(s[i:])
+			if err != nil {
+				return "", s, err
+			}
+			// the loop increments once more
+			i += n - 1
+		case strings.HasPrefix(s[i:], "${"):
+			n, err := takeInterpolation(s[i+2:])
+			if err != nil {
+				return "", s, err
+			}
+			// the loop increments once more, to get behind }
+			i += 1 + n
 		}
+	}
 
-		escaped = false
+	return "", s, errors.New("unterminated string, expected " + delim)
+}
+
+// takeInterpolation returns the length of the expression in "${...}",
+// including the closing }. Strings and braces inside the expression are skipped.
+func takeInterpolation(s string) (int, error) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', 'r':
+			str, _, err := takeString(s[i:])
+			if err != nil {
+				return 0, err
+			}
+			if str != "" {
+				i += len(str) - 1
+			}
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i + 1, nil
+			}
+			depth--
+		}
+	}
+
+	return 0, errors.New("unterminated interpolation, expected }")
+}
+
+// decodeEscape decodes the escape sequence at the start of s, e.g. \n or \u{1F600}
+// It returns the decoded text and the number of bytes the sequence takes up.
+func decodeEscape(s string) (string, int, error) {
+	if len(s) < 2 {
+		return "", 0, errors.New("unterminated escape sequence")
+	}
+
+	switch s[1] {
+	case 'n':
+		return "\n", 2, nil
+	case 't':
+		return "\t", 2, nil
+	case 'r':
+		return "\r", 2, nil
+	case '0':
+		return "\x00", 2, nil
+	case '\\', '"', '\'', '$':
+		return s[1:2], 2, nil
+
+	// \xHH a single byte
+	case 'x':
+		if len(s) < 4 {
+			return "", 0, errors.New("invalid escape sequence " + s + ", expected \\xHH")
+		}
+		b, err := strconv.ParseUint(s[2:4], 16, 8)
+		if err != nil {
+			return "", 0, errors.New("invalid escape sequence " + s[:4] + ", expected \\xHH")
+		}
+		return string([]byte{byte(b)}), 4, nil
+
+	// \u{H...} a unicode code point, up to 6 hex digits
+	case 'u':
+		end := strings.IndexByte(s, '}')
+		if !strings.HasPrefix(s[2:], "{") || end < 0 || end < 4 || end > 9 {
+			return "", 0, errors.New("invalid escape sequence, expected \\u{H...} with 1 to 6 hex digits")
+		}
+		r, err := strconv.ParseUint(s[3:end], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return "", 0, errors.New("invalid unicode escape " + s[:end+1])
+		}
+		return string(rune(r)), end + 1, nil
+	}
+
+	_, size := utf8.DecodeRuneInString(s[1:])
+	return "", 0, errors.New("invalid escape sequence " + s[:1+size])
+}
+
+// dedent removes the indentation all lines of a multi-line string have in common.
+// A line break right after the opening """ and a blank last line, containing the closing """, are dropped.
+func dedent(s string) string {
+	if strings.HasPrefix(s, "\r\n") {
+		s = s[2:]
+	} else {
+		s = strings.TrimPrefix(s, "\n")
+	}
+
+	lines := strings.Split(s, "\n")
+	if last := lines[len(lines)-1]; len(lines) > 1 && strings.TrimSpace(last) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+
+	for i, line := range lines {
+		if len(line) >= indent && indent >= 0 {
+			lines[i] = line[indent:]
+		} else {
+			// blank lines may be shorter than the indentation
+			lines[i] = strings.TrimLeft(line, " \t")
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// takeRegex takes a regular expression literal #/.../
+// a / inside of the expression is written as \/
+func takeRegex(s string) (string, string, error) {
+	if !strings.HasPrefix(s, "#/") {
+		return "", s, nil
+	}
+
+	for i := 2; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '/':
+			return s[:i+1], s[i+1:], nil
+		}
+	}
+
+	return "", s, errors.New("unterminated regular expression, expected /")
+}
+
+// takeCommand takes a shell command literal `...`
+// a backtick inside of the command is written as \`
+func takeCommand(s string) (string, string, error) {
+	if !strings.HasPrefix(s, "`") {
+		return "", s, nil
+	}
 
-		if c == '\\' {
-			escaped = true
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case strings.HasPrefix(s[i:], "${"):
+			n, err := takeInterpolation(s[i+2:])
+			if err != nil {
+				return "", s, err
+			}
+			i += 1 + n
+		case s[i] == '`':
+			return s[:i+1], s[i+1:], nil
 		}
 	}
 
-	return s, "" // error here. Expected "
+	return "", s, errors.New("unterminated command, expected `")
 }
@@ -5,7 +5,11 @@ import (
 )
 
 func TestTakeString(t *testing.T) {
-	s, rest := takeString("\"hello\"lots of love")
+	s, rest, err := takeString("\"hello\"lots of love")
+
+	if err != nil {
+		t.Error("unexpected error", err)
+	}
 
 	if s != "\"hello\"" {
 		t.Error("expected hello string, got", s)
@@ -29,3 +33,100 @@ func TestTakeWhitespace(t *testing.T) {
 	}
 
 }
+
+func TestTakeStringForms(t *testing.T) {
+	cases := [][]string{
+		{`"a\"b" rest`, `"a\"b"`},
+		{`r"C:\path" rest`, `r"C:\path"`},
+		{`"""a "quoted" b""" rest`, `"""a "quoted" b"""`},
+		{`r"""\d"""`, `r"""\d"""`},
+		{`"" ""`, `""`},
+	}
+
+	for _, c := range cases {
+		s, _, err := takeString(c[0])
+		if err != nil {
+			t.Error(c[0], "unexpected error", err)
+		}
+
+		if s != c[1] {
+			t.Error("expected", c[1], "got", s)
+		}
+	}
+
+	for _, invalid := range []string{`"\q"`, `"\x4"`, `"\u{110000}"`, `"\u{}"`, `"open`, `"""open"`, `"${1"`} {
+		if _, _, err := takeString(invalid); err == nil {
+			t.Error("expected an error for", invalid)
+		}
+	}
+}
+
+func TestTakeShebang(t *testing.T) {
+	shebang, rest := takeShebang("#!/usr/bin/env interpreter\n(print 1)")
+	if shebang != "#!/usr/bin/env interpreter" || rest != "\n(print 1)" {
+		t.Error("unexpected split", shebang, rest)
+	}
+
+	if shebang, _ := takeShebang("(print 1)"); shebang != "" {
+		t.Error("expected no shebang, got", shebang)
+	}
+}
+
+func TestTakeCommand(t *testing.T) {
+	cases := [][]string{
+		{"`ls -l` rest", "`ls -l`"},
+		{"`echo \\`` rest", "`echo \\``"},
+		{"`echo ${(str \"`\")}` rest", "`echo ${(str \"`\")}`"},
+	}
+
+	for _, c := range cases {
+		s, _, err := takeCommand(c[0])
+		if err != nil {
+			t.Error(c[0], "unexpected error", err)
+		}
+
+		if s != c[1] {
+			t.Error("expected", c[1], "got", s)
+		}
+	}
+
+	if _, _, err := takeCommand("`open"); err == nil {
+		t.Error("expected an error for an unterminated command")
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	cases := [][]string{
+		{`none`, "none"},
+		{`a\nb\tc\\d\re`, "a\nb\tc\\d\re"},
+		{`\"\'\$`, `"'$`},
+		{`\0`, "\x00"},
+		{`\x41\x7a`, "Az"},
+		{`\u{e4}\u{1F600}`, "ä😀"},
+	}
+
+	for _, c := range cases {
+		s, err := unescape(c[0])
+		if err != nil {
+			t.Error(c[0], "unexpected error", err)
+		}
+
+		if s != c[1] {
+			t.Errorf("%s: expected %q got %q", c[0], c[1], s)
+		}
+	}
+}
+
+func TestDedent(t *testing.T) {
+	cases := [][]string{
+		{"\n    a\n      b\n    ", "a\n  b"},
+		{"\n\ta\n\n\tb\n", "a\n\nb"},
+		{"single line", "single line"},
+	}
+
+	for _, c := range cases {
+		if s := dedent(c[0]); s != c[1] {
+			t.Errorf("%q: expected %q got %q", c[0], c[1], s)
+		}
+	}
+}
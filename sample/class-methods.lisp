@@ -2,7 +2,6 @@
        fun +(self other)
             (Point (+ (x self) (x other)) (+ (y self) (x self))))
 
-(print (let a (Point 1 2)
-(let b (Point 2 3)
-  (+ a b)
-  )))
+(print (let (a (Point 1 2)
+             b (Point 2 3))
+  (+ a b)))
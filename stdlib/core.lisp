@@ -0,0 +1,16 @@
+; basics, used by the other modules
+
+(fun not (x) (if x false true))
+
+(fun nil? (x) (== x nil))
+(fun some? (x) (!= x nil))
+
+; (or-default x 0) is 0, if x is nil
+(fun or-default (x default) (if (== x nil) default x))
+
+(fun identity (x) x)
+(fun inc (x) (+ x 1))
+(fun dec (x) (- x 1))
+
+(fun min (a ..xs) (reduce xs (fun (m x) (if (< x m) x m)) a))
+(fun max (a ..xs) (reduce xs (fun (m x) (if (> x m) x m)) a))
@@ -0,0 +1,36 @@
+; helpers for arrays and other sequences
+
+(import (only not) from "std/core")
+
+(fun empty? (xs) (== (len xs) 0))
+
+; first and last are nil for an empty sequence
+(fun first (xs) (get xs 0))
+(fun last (xs) (get xs (- (len xs) 1)))
+(fun rest (xs) (if (empty? xs) xs (slice xs 1)))
+
+(fun sum (xs) (reduce xs + 0))
+(fun product (xs) (reduce xs * 1))
+
+; (find-first xs f) is the first element f holds for, or nil
+(fun find-first (xs f)
+    (for (x in xs)
+        (if (f x) (break x) nil)))
+
+(fun any? (xs f)
+    (== true (for (x in xs)
+        (if (f x) (break true) nil))))
+
+(fun all? (xs f)
+    (not (any? xs (fun (x) (not (f x))))))
+
+(fun count-if (xs f) (len (filter xs f)))
+
+; (zip [1 2] ["a" "b"]) is [[1 "a"] [2 "b"]], it's as long as the shorter sequence
+(fun zip (a b)
+    (map (range (if (< (len a) (len b)) (len a) (len b)))
+        (fun (i) [(get a i) (get b i)])))
+
+; (enumerate ["a" "b"]) is [[0 "a"] [1 "b"]]
+(fun enumerate (xs)
+    (map (range (len xs)) (fun (i) [i (get xs i)])))
@@ -0,0 +1,18 @@
+; results of operations, that can fail: (Ok value) or (Err error)
+
+(class Ok (value))
+(class Err (error))
+
+(fun ok? (r) (== (type r) "Ok"))
+(fun err? (r) (== (type r) "Err"))
+
+; unwrap fails for an Err
+(fun unwrap (r)
+    (if (ok? r) (value r) (throw (str "unwrap of " r))))
+
+(fun unwrap-or (r default)
+    (if (ok? r) (value r) default))
+
+; (map-ok r f) applies f to the value of an Ok, an Err stays as it is
+(fun map-ok (r f)
+    (if (ok? r) (Ok (f (value r))) r))
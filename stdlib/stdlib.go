@@ -0,0 +1,11 @@
+// Package stdlib is the part of the standard library, that is written in the language itself.
+// Every file is a module, importable as std/<name>, e.g. (import "std/lists").
+package stdlib
+
+import "embed"
+
+//go:embed *.lisp
+var Files embed.FS
+
+// Prelude lists the modules, whose definitions every program can use without importing them
+var Prelude = []string{"core", "lists", "string", "result"}
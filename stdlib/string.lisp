@@ -0,0 +1,19 @@
+; helpers for strings
+
+(fun blank? (s) (== (trim s) ""))
+
+; (words " a  b ") is ["a" "b"]
+(fun words (s)
+    (if (blank? s) [] (split-re (trim s) #/\s+/)))
+
+(fun capitalize (s)
+    (if (== s "") s (str (upper (substr s 0 1)) (substr s 1))))
+
+; (pad-left "7" 3 "0") is "007"
+(fun pad-left (s n (fill " "))
+    (let missing (- n (len s))
+        (if (> missing 0) (str (repeat fill missing) s) s)))
+
+(fun pad-right (s n (fill " "))
+    (let missing (- n (len s))
+        (if (> missing 0) (str s (repeat fill missing)) s)))
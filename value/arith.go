@@ -0,0 +1,532 @@
+package value
+
+import (
+	"errors"
+	"math"
+	"math/big"
+)
+
+// Arithmetic on numbers. Ints stay Ints, as soon as a Float is involved the result is a Float.
+// Ints that overflow are promoted to BigInts, BigInts that fit are demoted to Ints.
+// Rationals and Decimals are exact, see numeric for how the types combine.
+// All operations broadcast over arrays, see Broadcast.
+
+func Add(a Object, b Object) (Object, error) {
+	if v, ok := vectorized(a, b, addInts, addFloats); ok {
+		return v, nil
+	}
+	return Broadcast(a, b, add)
+}
+
+func Sub(a Object, b Object) (Object, error) {
+	if v, ok := vectorized(a, b, subInts, subFloats); ok {
+		return v, nil
+	}
+	return Broadcast(a, b, sub)
+}
+
+func Mul(a Object, b Object) (Object, error) {
+	if v, ok := vectorized(a, b, mulInts, mulFloats); ok {
+		return v, nil
+	}
+	return Broadcast(a, b, mul)
+}
+
+func Div(a Object, b Object) (Object, error) {
+	if v, ok := vectorized(a, b, divInts, divFloats); ok {
+		return v, nil
+	}
+	return Broadcast(a, b, div)
+}
+
+func Mod(a Object, b Object) (Object, error) {
+	if v, ok := vectorized(a, b, modInts, math.Mod); ok {
+		return v, nil
+	}
+	return Broadcast(a, b, mod)
+}
+
+func Pow(a Object, b Object) (Object, error) {
+	if v, ok := vectorized(a, b, powInts, math.Pow); ok {
+		return v, nil
+	}
+	return Broadcast(a, b, pow)
+}
+
+func add(a Object, b Object) (Object, error) {
+	return numeric("+", a, b,
+		func(x, y int64) (Object, error) {
+			if r, ok := addInts(x, y); ok {
+				return NewInt(r), nil
+			}
+			return NewBigInt(new(big.Int).Add(big.NewInt(x), big.NewInt(y))), nil
+		},
+		func(x, y *big.Int) (Object, error) { return NewBigInt(new(big.Int).Add(x, y)), nil },
+		func(x, y *big.Rat) (*big.Rat, error) { return new(big.Rat).Add(x, y), nil },
+		func(x, y float64) (Object, error) { return NewFloat(x + y), nil })
+}
+
+func sub(a Object, b Object) (Object, error) {
+	return numeric("-", a, b,
+		func(x, y int64) (Object, error) {
+			if r, ok := subInts(x, y); ok {
+				return NewInt(r), nil
+			}
+			return NewBigInt(new(big.Int).Sub(big.NewInt(x), big.NewInt(y))), nil
+		},
+		func(x, y *big.Int) (Object, error) { return NewBigInt(new(big.Int).Sub(x, y)), nil },
+		func(x, y *big.Rat) (*big.Rat, error) { return new(big.Rat).Sub(x, y), nil },
+		func(x, y float64) (Object, error) { return NewFloat(x - y), nil })
+}
+
+func mul(a Object, b Object) (Object, error) {
+	return numeric("*", a, b,
+		func(x, y int64) (Object, error) {
+			if r, ok := mulInts(x, y); ok {
+				return NewInt(r), nil
+			}
+			return NewBigInt(new(big.Int).Mul(big.NewInt(x), big.NewInt(y))), nil
+		},
+		func(x, y *big.Int) (Object, error) { return NewBigInt(new(big.Int).Mul(x, y)), nil },
+		func(x, y *big.Rat) (*big.Rat, error) { return new(big.Rat).Mul(x, y), nil },
+		func(x, y float64) (Object, error) { return NewFloat(x * y), nil })
+}
+
+// dividing Ints results in an Int, if there is no remainder. Otherwise in an exact Rational
+func div(a Object, b Object) (Object, error) {
+	return numeric("/", a, b,
+		func(x, y int64) (Object, error) {
+			if y == 0 {
+				return nil, errDivisionByZero
+			}
+			if r, ok := divInts(x, y); ok {
+				return NewInt(r), nil
+			}
+			// MinInt64 / -1 overflows, so it ends up here as well
+			return NewRational(big.NewRat(x, y)), nil
+		},
+		func(x, y *big.Int) (Object, error) {
+			if y.Sign() == 0 {
+				return nil, errDivisionByZero
+			}
+			return NewRational(new(big.Rat).SetFrac(x, y)), nil
+		},
+		func(x, y *big.Rat) (*big.Rat, error) {
+			if y.Sign() == 0 {
+				return nil, errDivisionByZero
+			}
+			return new(big.Rat).Quo(x, y), nil
+		},
+		func(x, y float64) (Object, error) { return NewFloat(x / y), nil })
+}
+
+func mod(a Object, b Object) (Object, error) {
+	return numeric("%", a, b,
+		func(x, y int64) (Object, error) {
+			if y == 0 {
+				return nil, errDivisionByZero
+			}
+			return NewInt(x % y), nil
+		},
+		func(x, y *big.Int) (Object, error) {
+			if y.Sign() == 0 {
+				return nil, errDivisionByZero
+			}
+			return NewBigInt(new(big.Int).Rem(x, y)), nil
+		},
+		func(x, y *big.Rat) (*big.Rat, error) {
+			if y.Sign() == 0 {
+				return nil, errDivisionByZero
+			}
+			// like for Ints, the quotient is truncated and the result has the sign of x
+			q := new(big.Rat).Quo(x, y)
+			t := new(big.Rat).SetInt(new(big.Int).Quo(q.Num(), q.Denom()))
+			return t.Mul(t, y).Sub(x, t), nil
+		},
+		func(x, y float64) (Object, error) { return NewFloat(math.Mod(x, y)), nil })
+}
+
+func pow(a Object, b Object) (Object, error) {
+	return numeric("pow", a, b,
+		func(x, y int64) (Object, error) {
+			if y < 0 {
+				return NewFloat(math.Pow(float64(x), float64(y))), nil
+			}
+			if r, ok := powInts(x, y); ok {
+				return NewInt(r), nil
+			}
+			if err := checkPowSize(big.NewInt(x), big.NewInt(y)); err != nil {
+				return nil, err
+			}
+			return NewBigInt(new(big.Int).Exp(big.NewInt(x), big.NewInt(y), nil)), nil
+		},
+		func(x, y *big.Int) (Object, error) {
+			if y.Sign() < 0 {
+				fx, _ := new(big.Float).SetInt(x).Float64()
+				fy, _ := new(big.Float).SetInt(y).Float64()
+				return NewFloat(math.Pow(fx, fy)), nil
+			}
+			if err := checkPowSize(x, y); err != nil {
+				return nil, err
+			}
+			return NewBigInt(new(big.Int).Exp(x, y, nil)), nil
+		},
+		func(x, y *big.Rat) (*big.Rat, error) {
+			// only integral exponents are exact
+			if !y.IsInt() || !y.Num().IsInt64() || x.Sign() == 0 && y.Sign() < 0 {
+				return nil, errInexact
+			}
+			n := y.Num()
+			if err := checkPowSize(x.Num(), new(big.Int).Abs(n)); err != nil {
+				return nil, err
+			}
+			if err := checkPowSize(x.Denom(), new(big.Int).Abs(n)); err != nil {
+				return nil, err
+			}
+			r := new(big.Rat).SetFrac(
+				new(big.Int).Exp(x.Num(), new(big.Int).Abs(n), nil),
+				new(big.Int).Exp(x.Denom(), new(big.Int).Abs(n), nil))
+			if n.Sign() < 0 {
+				r.Inv(r)
+			}
+			return r, nil
+		},
+		func(x, y float64) (Object, error) { return NewFloat(math.Pow(x, y)), nil })
+}
+
+// maxPowBits bounds the size of the BigInts pow computes, so that a typo doesn't run until the memory is exhausted
+const maxPowBits = 1 << 26
+
+// checkPowSize fails, if x to the power of y has more than maxPowBits bits
+func checkPowSize(x, y *big.Int) error {
+	if x.CmpAbs(big.NewInt(1)) <= 0 {
+		return nil
+	}
+
+	// |x| is at least 2^(bits-1), so the result has at least (bits-1)*y bits
+	bits := new(big.Int).Mul(big.NewInt(int64(x.BitLen()-1)), y)
+	if bits.Cmp(big.NewInt(maxPowBits)) > 0 {
+		return errors.New("pow: the result of " + x.String() + " to the power of " + y.String() + " is too large")
+	}
+	return nil
+}
+
+var errDivisionByZero = errors.New("division by zero")
+
+// errInexact is returned by exact operations, if the result is not a Rational, e.g. (pow 2/3 1/2).
+// The operation is then done on Floats.
+var errInexact = errors.New("inexact result")
+
+// numeric dispatches along the numeric tower Int < BigInt < Rational < Decimal < Float.
+// The result has the type of the higher operand, except that results of Ints and BigInts
+// can still be Floats (e.g. for division) and Rationals that are integral are demoted.
+//
+// It calls ints, if both operands are Ints, bigs if both are Ints or BigInts,
+// rats for exact results, if neither is a Float, and floats otherwise.
+func numeric(
+	name string,
+	a Object, b Object,
+	ints func(x, y int64) (Object, error),
+	bigs func(x, y *big.Int) (Object, error),
+	rats func(x, y *big.Rat) (*big.Rat, error),
+	floats func(x, y float64) (Object, error),
+) (Object, error) {
+	if x, ok := a.(*IntClass); ok {
+		if y, ok := b.(*IntClass); ok {
+			return ints(x.value, y.value)
+		}
+	}
+
+	if x, ok := toBig(a); ok {
+		if y, ok := toBig(b); ok {
+			return bigs(x, y)
+		}
+	}
+
+	_, floatA := a.(*FloatClass)
+	_, floatB := b.(*FloatClass)
+	if !floatA && !floatB {
+		if x, ok := ToRat(a); ok {
+			if y, ok := ToRat(b); ok {
+				r, err := rats(x, y)
+				if err == nil {
+					return exact(name, a, b, r)
+				}
+				if err != errInexact {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	x, okA := toFloat(a)
+	y, okB := toFloat(b)
+	if !okA || !okB {
+		return nil, errors.New("can't apply " + name + " to " + a.Class() + " and " + b.Class())
+	}
+
+	return floats(x, y)
+}
+
+// exact returns a Decimal, if a or b is one, and a Rational otherwise.
+// The scale of the Decimal is the bigger one of the operands, for multiplication their sum,
+// so that no digits are lost. The first Decimal decides the rounding mode.
+func exact(name string, a Object, b Object, r *big.Rat) (Object, error) {
+	x, okA := a.(*Decimal)
+	y, okB := b.(*Decimal)
+	if !okA && !okB {
+		return NewRational(r), nil
+	}
+
+	var scaleA, scaleB int
+	var mode RoundingMode
+	if okB {
+		scaleB, mode = y.scale, y.mode
+	}
+	if okA {
+		scaleA, mode = x.scale, x.mode
+	}
+
+	scale := scaleA
+	if scaleB > scale {
+		scale = scaleB
+	}
+
+	switch name {
+	case "*":
+		scale = scaleA + scaleB
+	case "pow":
+		if n, ok := b.(*IntClass); ok && okA && n.value > 0 {
+			scale = scaleA * int(n.value)
+		}
+	}
+
+	return NewDecimal(r, scale, mode)
+}
+
+func toFloat(o Object) (float64, bool) {
+	switch o := o.(type) {
+	case *IntClass:
+		return float64(o.value), true
+	case *FloatClass:
+		return o.value, true
+	case *BigInt:
+		f, _ := new(big.Float).SetInt(o.value).Float64()
+		return f, true
+	case *Rational:
+		f, _ := o.value.Float64()
+		return f, true
+	case *Decimal:
+		f, _ := o.Rat().Float64()
+		return f, true
+	}
+
+	return 0, false
+}
+
+// Kernels for typed arrays, see vectorized.
+// An intKernel returns false, if the result is not an Int (e.g. division by zero or an overflow),
+// the whole operation then falls back to the generic, boxed implementation.
+type intKernel func(x, y int64) (int64, bool)
+type floatKernel func(x, y float64) float64
+
+func addInts(x, y int64) (int64, bool) {
+	r := x + y
+	// overflow, if both operands have the same sign and the result a different one
+	return r, (x >= 0) != (y >= 0) || (r >= 0) == (x >= 0)
+}
+
+func subInts(x, y int64) (int64, bool) {
+	r := x - y
+	// overflow, if the operands have different signs and the result differs from x
+	return r, (x >= 0) == (y >= 0) || (r >= 0) == (x >= 0)
+}
+
+func mulInts(x, y int64) (int64, bool) {
+	if x == 0 || y == 0 {
+		return 0, true
+	}
+
+	r := x * y
+	if r/y != x || (x == -1 && y == math.MinInt64) || (y == -1 && x == math.MinInt64) {
+		return 0, false
+	}
+	return r, true
+}
+
+func divInts(x, y int64) (int64, bool) {
+	if y == 0 || x%y != 0 || (x == math.MinInt64 && y == -1) {
+		return 0, false
+	}
+	return x / y, true
+}
+
+func modInts(x, y int64) (int64, bool) {
+	if y == 0 {
+		return 0, false
+	}
+	return x % y, true
+}
+
+// exponentiation by squaring
+func powInts(x, y int64) (int64, bool) {
+	if y < 0 {
+		return 0, false
+	}
+
+	r := int64(1)
+	for y > 0 {
+		var ok bool
+		if y&1 == 1 {
+			if r, ok = mulInts(r, x); !ok {
+				return 0, false
+			}
+		}
+		y >>= 1
+		if y > 0 {
+			if x, ok = mulInts(x, x); !ok {
+				return 0, false
+			}
+		}
+	}
+	return r, true
+}
+
+func addFloats(x, y float64) float64 { return x + y }
+func subFloats(x, y float64) float64 { return x - y }
+func mulFloats(x, y float64) float64 { return x * y }
+func divFloats(x, y float64) float64 { return x / y }
+
+// vectorized computes the result directly on the go slices of typed arrays,
+// without boxing every element. ok is false, if the operands are not suited for this.
+// Arrays of length 1 are stretched, like in Broadcast.
+//
+//	IntArray|ByteArray   op IntArray|ByteArray|Int         -> IntArray
+//	FloatArray           op any typed array|Float|Int      -> FloatArray
+//	IntArray|ByteArray   op Float                          -> FloatArray
+//
+// Ints that overflow, or a division with a remainder, are no Ints anymore.
+// Then ok is false, and the result is a plain Array of the boxed values.
+func vectorized(a Object, b Object, ints intKernel, floats floatKernel) (Object, bool) {
+	if v, ok := vectorizedInts(a, b, ints); ok {
+		return v, true
+	}
+
+	_, floatA := a.(*FloatArray)
+	_, floatB := b.(*FloatArray)
+	_, scalarA := a.(*FloatClass)
+	_, scalarB := b.(*FloatClass)
+	_, intsA, _, _ := intOperand(a)
+	_, intsB, _, _ := intOperand(b)
+
+	if !(floatA || floatB || (intsA && scalarB) || (scalarA && intsB)) {
+		return nil, false
+	}
+
+	xs, x, arrA, okA := floatOperand(a)
+	ys, y, arrB, okB := floatOperand(b)
+	if !okA || !okB {
+		return nil, false
+	}
+
+	n, ok := vectorLength(len(xs), arrA, len(ys), arrB)
+	if !ok {
+		return nil, false
+	}
+	res := make([]float64, n)
+	for i := range res {
+		if arrA {
+			x = xs[i%len(xs)]
+		}
+		if arrB {
+			y = ys[i%len(ys)]
+		}
+		res[i] = floats(x, y)
+	}
+	return NewFloatArray(res), true
+}
+
+func vectorizedInts(a Object, b Object, ints intKernel) (Object, bool) {
+	xs, arrA, x, okA := intOperand(a)
+	ys, arrB, y, okB := intOperand(b)
+	if !okA || !okB || !(arrA || arrB) {
+		return nil, false
+	}
+
+	n, ok := vectorLength(len(xs), arrA, len(ys), arrB)
+	if !ok {
+		return nil, false
+	}
+	res := make([]int64, n)
+	for i := range res {
+		if arrA {
+			x = xs[i%len(xs)]
+		}
+		if arrB {
+			y = ys[i%len(ys)]
+		}
+		r, ok := ints(x, y)
+		if !ok {
+			return nil, false
+		}
+		res[i] = r
+	}
+	return NewIntArray(res), true
+}
+
+// vectorLength is the length of the result, ok is false if the lengths can't be broadcast
+func vectorLength(lenA int, arrA bool, lenB int, arrB bool) (int, bool) {
+	switch {
+	case !arrB || (arrA && lenA == lenB):
+		return lenA, true
+	case !arrA:
+		return lenB, true
+	case lenA == 1:
+		return lenB, true
+	case lenB == 1:
+		return lenA, true
+	}
+	return 0, false
+}
+
+// intOperand returns the elements of an IntArray or ByteArray, or an Int scalar.
+// The first boolean tells whether o is an array.
+func intOperand(o Object) ([]int64, bool, int64, bool) {
+	switch o := o.(type) {
+	case *IntArray:
+		return o.values, true, 0, true
+	case *ByteArray:
+		xs := make([]int64, len(o.values))
+		for i, v := range o.values {
+			xs[i] = int64(v)
+		}
+		return xs, true, 0, true
+	case *IntClass:
+		return nil, false, o.value, true
+	}
+	return nil, false, 0, false
+}
+
+// floatOperand returns the elements of a typed array as floats, or a scalar.
+// The first boolean tells whether o is an array.
+func floatOperand(o Object) ([]float64, float64, bool, bool) {
+	switch o := o.(type) {
+	case *FloatArray:
+		return o.values, 0, true, true
+	case *IntArray:
+		fs := make([]float64, len(o.values))
+		for i, v := range o.values {
+			fs[i] = float64(v)
+		}
+		return fs, 0, true, true
+	case *ByteArray:
+		fs := make([]float64, len(o.values))
+		for i, v := range o.values {
+			fs[i] = float64(v)
+		}
+		return fs, 0, true, true
+	}
+
+	f, ok := toFloat(o)
+	return nil, f, false, ok
+}
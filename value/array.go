@@ -23,3 +23,38 @@ func (a *Array) Str() string {
 func (a *Array) Class() string {
 	return "Array"
 }
+
+// NewArrayOf wraps values without copying them
+func NewArrayOf(values []Object) *Array {
+	return &Array{values}
+}
+
+func (a *Array) Len() int {
+	return len(a.values)
+}
+
+// At returns the element at index i. It panics if i is out of range, just like a go slice.
+func (a *Array) At(i int) Object {
+	return a.values[i]
+}
+
+func (a *Array) SetAt(i int, value Object) {
+	a.values[i] = value
+}
+
+// Push appends values in place
+func (a *Array) Push(values ...Object) {
+	a.values = append(a.values, values...)
+}
+
+// Values returns a copy of the elements, so that the array can't be changed from outside
+func (a *Array) Values() []Object {
+	values := make([]Object, len(a.values))
+	copy(values, a.values)
+	return values
+}
+
+// Slice returns a new array containing the elements from index `from` up to, but excluding, `to`
+func (a *Array) Slice(from int, to int) *Array {
+	return NewArrayOf(append([]Object(nil), a.values[from:to]...))
+}
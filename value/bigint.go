@@ -0,0 +1,80 @@
+package value
+
+import (
+	"math"
+	"math/big"
+)
+
+// BigInt holds integers that don't fit into an int64.
+// Arithmetic promotes Ints to BigInts on overflow and demotes results that fit back to Ints,
+// so a BigInt is never in the range of an Int. See NewBigInt.
+type BigInt struct {
+	value *big.Int
+}
+
+// NewBigInt returns an Int, if the value fits. The value must not be changed afterwards.
+func NewBigInt(value *big.Int) Object {
+	if value.IsInt64() {
+		return NewInt(value.Int64())
+	}
+
+	return &BigInt{value}
+}
+
+// Big returns the value of b. It must not be changed.
+func (b *BigInt) Big() *big.Int {
+	return b.value
+}
+
+func (b *BigInt) Boolean() bool {
+	return b.value.Sign() != 0
+}
+
+func (b *BigInt) Str() string {
+	return b.value.String()
+}
+
+func (b *BigInt) Class() string {
+	return "BigInt"
+}
+
+func (b *BigInt) Equal(other Object) bool {
+	c, err := b.Compare(other)
+	return err == nil && c == 0
+}
+
+func (b *BigInt) Hash() uint64 {
+	// has to hash like an equal Float
+	if f, acc := new(big.Float).SetInt(b.value).Float64(); acc == big.Exact {
+		return hashInt(int64(math.Float64bits(f)))
+	}
+	return hashString(b.value.String())
+}
+
+func (b *BigInt) Compare(other Object) (int, error) {
+	switch o := other.(type) {
+	case *BigInt:
+		return b.value.Cmp(o.value), nil
+	case *IntClass:
+		return b.value.Cmp(big.NewInt(o.value)), nil
+	case *FloatClass:
+		if math.IsNaN(o.value) {
+			return 0, notComparable(b, other)
+		}
+		return new(big.Float).SetInt(b.value).Cmp(big.NewFloat(o.value)), nil
+	case *Rational, *Decimal:
+		return compareRat(b, new(big.Rat).SetInt(b.value), other)
+	}
+	return 0, notComparable(b, other)
+}
+
+// toBig converts Ints and BigInts
+func toBig(o Object) (*big.Int, bool) {
+	switch o := o.(type) {
+	case *IntClass:
+		return big.NewInt(o.value), true
+	case *BigInt:
+		return o.value, true
+	}
+	return nil, false
+}
@@ -0,0 +1,114 @@
+package value
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Broadcast applies op element-wise, like numpy does.
+// Dimensions are lined up from the last one: the array with fewer dimensions
+// is paired with every element of the leading dimensions of the other one,
+// so a scalar is paired with every element of an array.
+// Arrays of the same number of dimensions need the same length, unless one of them has length 1,
+// which is stretched to the length of the other.
+// Typed arrays are treated like any other array here, the result is always an Array.
+func Broadcast(a Object, b Object, op func(a Object, b Object) (Object, error)) (Object, error) {
+	dimsA, dimsB := dimensions(a), dimensions(b)
+
+	switch {
+	case dimsA == 0 && dimsB == 0:
+		return op(a, b)
+
+	case dimsA > dimsB:
+		seqA := a.(Sequence)
+		return broadcastEach(seqA.Len(), func(i int) (Object, error) {
+			return Broadcast(seqA.At(i), b, op)
+		})
+
+	case dimsB > dimsA:
+		seqB := b.(Sequence)
+		return broadcastEach(seqB.Len(), func(i int) (Object, error) {
+			return Broadcast(a, seqB.At(i), op)
+		})
+	}
+
+	seqA, seqB := a.(Sequence), b.(Sequence)
+	lenA, lenB := seqA.Len(), seqB.Len()
+	if lenA != lenB && lenA != 1 && lenB != 1 {
+		return nil, errors.New(fmt.Sprint("shape mismatch: can't broadcast arrays of length ", lenA, " and ", lenB))
+	}
+
+	n := lenA
+	if lenA == 1 {
+		n = lenB
+	}
+	return broadcastEach(n, func(i int) (Object, error) {
+		return Broadcast(seqA.At(i%lenA), seqB.At(i%lenB), op)
+	})
+}
+
+func broadcastEach(n int, f func(i int) (Object, error)) (Object, error) {
+	values := make([]Object, 0, n)
+	for i := 0; i < n; i++ {
+		v, err := f(i)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return NewArrayOf(values), nil
+}
+
+// dimensions is 0 for a scalar, and one more than the dimensions of the first element for an array
+func dimensions(o Object) int {
+	seq, ok := o.(Sequence)
+	if !ok {
+		return 0
+	}
+	if seq.Len() == 0 {
+		return 1
+	}
+	return 1 + dimensions(seq.At(0))
+}
+
+// Select picks elements out of seq, keeping its type.
+// index is either a sequence of Bools of the same length (a mask),
+// or a sequence of Ints, the positions to pick.
+func Select(seq Sequence, index Sequence) (Sequence, error) {
+	positions := make([]int, 0)
+
+	if index.Len() == 0 {
+		return seq.Pick(positions), nil
+	}
+
+	if _, ok := index.At(0).(*BoolClass); ok {
+		if index.Len() != seq.Len() {
+			return nil, errors.New(fmt.Sprint("shape mismatch: mask of length ", index.Len(), " for array of length ", seq.Len()))
+		}
+
+		for i := 0; i < index.Len(); i++ {
+			b, ok := index.At(i).(*BoolClass)
+			if !ok {
+				return nil, errors.New("mask must only contain Bools, got " + index.At(i).Class())
+			}
+
+			if b.value {
+				positions = append(positions, i)
+			}
+		}
+		return seq.Pick(positions), nil
+	}
+
+	for i := 0; i < index.Len(); i++ {
+		n, ok := index.At(i).(*IntClass)
+		if !ok {
+			return nil, errors.New("index array must only contain Ints, got " + index.At(i).Class())
+		}
+
+		if n.value < 0 || n.value >= int64(seq.Len()) {
+			return nil, errors.New(fmt.Sprint("index ", n.value, " out of range for array of length ", seq.Len()))
+		}
+		positions = append(positions, int(n.value))
+	}
+	return seq.Pick(positions), nil
+}
@@ -0,0 +1,65 @@
+package value
+
+import "errors"
+
+// Channel is a queue of values, see the chan builtins.
+// There is no way to start a go routine in the language, so nobody could ever wake up a blocked send or receive.
+// Both fail instead of blocking.
+type Channel struct {
+	ch chan Object
+}
+
+func NewChannel(capacity int) *Channel {
+	return &Channel{make(chan Object, capacity)}
+}
+
+func (c *Channel) Boolean() bool {
+	return true
+}
+
+func (c *Channel) Str() string {
+	return "(chan)"
+}
+
+func (c *Channel) Class() string {
+	return "Channel"
+}
+
+// Send fails, if the channel is full or closed
+func (c *Channel) Send(v Object) (err error) {
+	// go panics when sending on a closed channel
+	defer func() {
+		if recover() != nil {
+			err = errors.New("send on closed channel")
+		}
+	}()
+
+	select {
+	case c.ch <- v:
+		return nil
+	default:
+		return errors.New("send on a full channel would block forever")
+	}
+}
+
+// Receive returns the next value. ok is false, if the channel is closed and empty.
+// It fails, if the channel is empty, but still open.
+func (c *Channel) Receive() (v Object, ok bool, err error) {
+	select {
+	case v, ok = <-c.ch:
+		return v, ok, nil
+	default:
+		return nil, false, errors.New("receive on an empty channel would block forever, close it after the last send")
+	}
+}
+
+func (c *Channel) Close() (err error) {
+	defer func() {
+		if recover() != nil {
+			err = errors.New("close of closed channel")
+		}
+	}()
+
+	close(c.ch)
+	return nil
+}
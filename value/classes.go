@@ -11,8 +11,14 @@ type ClassInfo struct {
 	size     int
 	fieldIds map[string]int
 	methods  map[string](Function)
+	invoke   Invoker
 }
 
+// Invoker calls a method defined in the language.
+// The value package can't evaluate functions itself,
+// the interpreter provides this, so classes can override `==`, `hash` and `compare`.
+type Invoker func(fn *Function, args []Object) (Object, error)
+
 func NewClassInfo(name string, fields []string, functions []ast.FunctionDefinition) (ClassInfo, error) {
 	size := len(fields)
 
@@ -33,10 +39,27 @@ func NewClassInfo(name string, fields []string, functions []ast.FunctionDefiniti
 			return ClassInfo{}, errors.New(f.Name + "cant be both a field and a method on class" + name)
 		}
 
-		methods[f.Name] = Function{Args: f.Args, Body: f.Body}
+		fn, err := NewFunction(f.Signature, f.Body)
+		if err != nil {
+			return ClassInfo{}, err
+		}
+		fn.Name = f.Name
+		methods[f.Name] = *fn
 	}
 
-	return ClassInfo{name, size, fieldIds, methods}, nil
+	return ClassInfo{name: name, size: size, fieldIds: fieldIds, methods: methods}, nil
+}
+
+func (c *ClassInfo) SetInvoker(invoke Invoker) {
+	c.invoke = invoke
+}
+
+// SetGlobals sets the globals of the module defining the class, which its methods use
+func (c *ClassInfo) SetGlobals(globals map[string]*Binding) {
+	for name, fn := range c.methods {
+		fn.Globals = globals
+		c.methods[name] = fn
+	}
 }
 
 func (c *ClassInfo) MakeInstance(values []Object) (Object, error) {
@@ -84,6 +107,20 @@ func (c *Class) Get(ident string) (Object, error) {
 	return nil, errors.New("no field " + ident + " on class " + c.info.name)
 }
 
+// Fields returns the values of all fields, in the order of the class definition
+func (c *Class) Fields() []Object {
+	return append([]Object(nil), c.fields...)
+}
+
+// FieldNames returns the names of the fields, in the order of the class definition
+func (c *Class) FieldNames() []string {
+	names := make([]string, len(c.fields))
+	for name, id := range c.info.fieldIds {
+		names[id] = name
+	}
+	return names
+}
+
 // TODO this is the place to do final fields
 func (c *Class) Set(ident string, value Object) error {
 	if id, ok := c.info.fieldIds[ident]; ok {
@@ -108,3 +145,75 @@ func (c *Class) Str() string {
 func (c *Class) Info() *ClassInfo {
 	return c.info
 }
+
+// callOverride calls the method ident on c, if the class defines it.
+func (c *Class) callOverride(ident string, args ...Object) (Object, bool, error) {
+	fn, ok := c.info.methods[ident]
+	if !ok || c.info.invoke == nil {
+		return nil, false, nil
+	}
+
+	res, err := c.info.invoke(&fn, append([]Object{c}, args...))
+	return res, true, err
+}
+
+// Classes are compared field by field, unless they define `==`
+func (c *Class) Equal(other Object) bool {
+	if res, ok, err := c.callOverride("==", other); ok {
+		return err == nil && res.Boolean()
+	}
+
+	o, ok := other.(*Class)
+	if !ok || o.info != c.info {
+		return false
+	}
+
+	for i := range c.fields {
+		if !Equal(c.fields[i], o.fields[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Hash combines the hashes of all fields, unless the class defines `hash`.
+// A failing `hash` method falls back to hashing the fields.
+func (c *Class) Hash() uint64 {
+	if res, ok, err := c.callOverride("hash"); ok && err == nil {
+		if i, ok := res.(*IntClass); ok {
+			return uint64(i.value)
+		}
+	}
+
+	h := hashString(c.info.name)
+	for _, v := range c.fields {
+		h = hashCombine(h, hashOf(v))
+	}
+	return h
+}
+
+// Compare orders instances of the same class field by field, unless the class defines `compare`
+func (c *Class) Compare(other Object) (int, error) {
+	if res, ok, err := c.callOverride("compare", other); ok {
+		if err != nil {
+			return 0, err
+		}
+		if i, ok := res.(*IntClass); ok {
+			return compareInts(i.value, 0), nil
+		}
+		return 0, errors.New("compare on class " + c.info.name + " must return an Int, got " + res.Class())
+	}
+
+	o, ok := other.(*Class)
+	if !ok || o.info != c.info {
+		return 0, notComparable(c, other)
+	}
+
+	for i := range c.fields {
+		cmp, err := Compare(c.fields[i], o.fields[i])
+		if err != nil || cmp != 0 {
+			return cmp, err
+		}
+	}
+	return 0, nil
+}
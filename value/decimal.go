@@ -0,0 +1,164 @@
+package value
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// Decimal is a fixed-point number with a number of digits after the decimal point, its scale.
+// Results of arithmetic are rounded to the scale using the rounding mode of the Decimal.
+type Decimal struct {
+	// the value is unscaled / 10^scale
+	unscaled *big.Int
+	scale    int
+	mode     RoundingMode
+}
+
+type RoundingMode int
+
+const (
+	HalfEven RoundingMode = iota
+	HalfUp
+	HalfDown
+	Up
+	Down
+	Ceiling
+	Floor
+)
+
+var roundingModes = []string{"half-even", "half-up", "half-down", "up", "down", "ceiling", "floor"}
+
+func (m RoundingMode) String() string {
+	return roundingModes[m]
+}
+
+func ParseRoundingMode(s string) (RoundingMode, error) {
+	for i, name := range roundingModes {
+		if name == s {
+			return RoundingMode(i), nil
+		}
+	}
+	return 0, errors.New("unknown rounding mode " + s + ", expected one of " + strings.Join(roundingModes, ", "))
+}
+
+// NewDecimal rounds value to scale digits after the decimal point
+func NewDecimal(value *big.Rat, scale int, mode RoundingMode) (*Decimal, error) {
+	if scale < 0 {
+		return nil, errors.New("the scale of a decimal can't be negative")
+	}
+
+	n := new(big.Rat).Mul(value, new(big.Rat).SetInt(pow10(scale)))
+	return &Decimal{round(n, mode), scale, mode}, nil
+}
+
+// ParseDecimal parses e.g. "-12.50", the scale is the number of digits after the point
+func ParseDecimal(s string, mode RoundingMode) (*Decimal, error) {
+	digits := s
+	if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		digits = digits[1:]
+	}
+
+	integral, fraction, _ := strings.Cut(digits, ".")
+	if integral == "" && fraction == "" || strings.Trim(integral+fraction, "0123456789") != "" {
+		return nil, errors.New("invalid decimal " + s)
+	}
+
+	unscaled, _ := new(big.Int).SetString(integral+fraction, 10)
+	if strings.HasPrefix(s, "-") {
+		unscaled.Neg(unscaled)
+	}
+	return &Decimal{unscaled, len(fraction), mode}, nil
+}
+
+func (d *Decimal) Scale() int {
+	return d.scale
+}
+
+func (d *Decimal) Mode() RoundingMode {
+	return d.mode
+}
+
+// Rat returns the exact value of d
+func (d *Decimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.unscaled, pow10(d.scale))
+}
+
+func (d *Decimal) Boolean() bool {
+	return d.unscaled.Sign() != 0
+}
+
+func (d *Decimal) Str() string {
+	digits := new(big.Int).Abs(d.unscaled).String()
+	if len(digits) <= d.scale {
+		digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+	}
+
+	s := digits
+	if d.scale > 0 {
+		s = digits[:len(digits)-d.scale] + "." + digits[len(digits)-d.scale:]
+	}
+	if d.unscaled.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+func (d *Decimal) Class() string {
+	return "Decimal"
+}
+
+// Decimals are equal to all numbers with the same value, regardless of the scale
+func (d *Decimal) Equal(other Object) bool {
+	c, err := d.Compare(other)
+	return err == nil && c == 0
+}
+
+func (d *Decimal) Hash() uint64 {
+	return hashRat(d.Rat())
+}
+
+func (d *Decimal) Compare(other Object) (int, error) {
+	return compareRat(d, d.Rat(), other)
+}
+
+// round rounds r to an integer
+func round(r *big.Rat, mode RoundingMode) *big.Int {
+	q, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if rem.Sign() == 0 {
+		return q
+	}
+
+	// q is truncated towards zero, decide whether to go one step away from it
+	sign := r.Sign()
+	half := new(big.Int).Abs(rem)
+	half.Lsh(half, 1)
+	c := half.Cmp(r.Denom())
+
+	away := false
+	switch mode {
+	case HalfEven:
+		away = c > 0 || c == 0 && q.Bit(0) == 1
+	case HalfUp:
+		away = c >= 0
+	case HalfDown:
+		away = c > 0
+	case Up:
+		away = true
+	case Down:
+		away = false
+	case Ceiling:
+		away = sign > 0
+	case Floor:
+		away = sign < 0
+	}
+
+	if away {
+		q.Add(q, big.NewInt(int64(sign)))
+	}
+	return q
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -21,3 +21,7 @@ func (f FloatClass) Str() string {
 func (f FloatClass) Class() string {
 	return "Float"
 }
+
+func (f FloatClass) Value() float64 {
+	return f.value
+}
@@ -6,13 +6,31 @@ import (
 )
 
 type Function struct {
-	// optional variadic arguments are a nice thing to have
-	// VarArg string
-	Args []string
+	// empty for lambdas
+	Name string
+	ast.Signature
 	Body ast.Expression
+
+	// local variables captured by a lambda, when it was created
+	Closure map[string]*Binding
+
+	// the globals of the module defining the function
+	Globals map[string]*Binding
+}
+
+// Binding is a variable. It's shared by the scope declaring it and the closures capturing it,
+// so that assignments to mutable variables are visible in both.
+type Binding struct {
+	Value   Object
+	Mutable bool
 }
 
-func NewFunction(arguments []string, body ast.Expression) (*Function, error) {
+func NewFunction(signature ast.Signature, body ast.Expression) (*Function, error) {
+	arguments := signature.Args
+	if signature.Rest != "" {
+		arguments = append(append([]string(nil), arguments...), signature.Rest)
+	}
+
 	setArgs := make(map[string]bool, len(arguments))
 	for _, ident := range arguments {
 		if setArgs[ident] == true {
@@ -22,7 +40,25 @@ func NewFunction(arguments []string, body ast.Expression) (*Function, error) {
 		setArgs[ident] = true
 	}
 
-	return &Function{arguments, body}, nil
+	// positional arguments couldn't skip an optional one
+	optional := ""
+	for i, ident := range signature.Args {
+		if signature.Default(i) != nil {
+			optional = ident
+		} else if optional != "" {
+			return nil, errors.New("required argument " + ident + " follows optional argument " + optional)
+		}
+	}
+
+	return &Function{Signature: signature, Body: body}, nil
+}
+
+// Describe returns the signature, e.g. (f a [b] ..rest)
+func (f *Function) Describe() string {
+	if f.Name == "" {
+		return f.Format("fun")
+	}
+	return f.Format(f.Name)
 }
 
 func (f *Function) Boolean() bool {
@@ -21,3 +21,7 @@ func (i IntClass) Str() string {
 func (i IntClass) Class() string {
 	return "Int"
 }
+
+func (i IntClass) Value() int64 {
+	return i.value
+}
@@ -0,0 +1,84 @@
+package value
+
+import "io"
+
+// Iterator is a lazy sequence, its elements are computed one after another, when they are pulled.
+// It can only be consumed once, e.g. (lines "file") reads the file while it is iterated.
+type Iterator struct {
+	next  func() (Object, bool, error)
+	close func()
+	done  bool
+}
+
+// NewIterator expects next to return false, once there are no more elements
+func NewIterator(next func() (Object, bool, error)) *Iterator {
+	return &Iterator{next: next}
+}
+
+// NewClosingIterator calls close once the iterator is exhausted, failed or closed early,
+// e.g. to close the file it reads from
+func NewClosingIterator(next func() (Object, bool, error), close func()) *Iterator {
+	return &Iterator{next: next, close: close}
+}
+
+// Next returns the next element, ok is false once the iterator is exhausted or failed
+func (it *Iterator) Next() (v Object, ok bool, err error) {
+	if it.done {
+		return nil, false, nil
+	}
+
+	v, ok, err = it.next()
+	if !ok || err != nil {
+		it.Close()
+		return nil, false, err
+	}
+	return v, true, nil
+}
+
+// Close ends the iterator before it is exhausted, afterwards it is empty
+func (it *Iterator) Close() {
+	it.done = true
+	if it.close != nil {
+		close := it.close
+		it.close = nil
+		close()
+	}
+}
+
+func (it *Iterator) Boolean() bool {
+	return true
+}
+
+func (it *Iterator) Str() string {
+	return "(iterator)"
+}
+
+func (it *Iterator) Class() string {
+	return "Iterator"
+}
+
+// Reader is a stream of input, e.g. stdin
+type Reader struct {
+	name   string
+	reader io.Reader
+}
+
+func NewReader(name string, reader io.Reader) *Reader {
+	return &Reader{name, reader}
+}
+
+func (r *Reader) Reader() io.Reader {
+	return r.reader
+}
+
+func (r *Reader) Boolean() bool {
+	return true
+}
+
+func (r *Reader) Str() string {
+	return r.name
+}
+
+func (r *Reader) Class() string {
+	return "Reader"
+}
@@ -0,0 +1,125 @@
+package value
+
+import "interpreter/ast"
+
+// List is quoted code, e.g. '(+ x 1).
+// Macros take lists as arguments and return the list, that replaces the call.
+type List struct {
+	values []Object
+}
+
+// NewList wraps values without copying them
+func NewList(values []Object) *List {
+	return &List{values}
+}
+
+func (l *List) Boolean() bool {
+	return true
+}
+
+func (l *List) Str() string {
+	s := "("
+	for i, v := range l.values {
+		if i > 0 {
+			s += " "
+		}
+		s += v.Str()
+	}
+	return s + ")"
+}
+
+func (l *List) Class() string {
+	return "List"
+}
+
+func (l *List) Len() int {
+	return len(l.values)
+}
+
+func (l *List) At(i int) Object {
+	return l.values[i]
+}
+
+func (l *List) Pick(positions []int) Sequence {
+	values := make([]Object, 0, len(positions))
+	for _, i := range positions {
+		values = append(values, l.values[i])
+	}
+	return NewList(values)
+}
+
+// Head returns the name of the first element, if it is a symbol, e.g. let for (let x 1 x)
+func (l *List) Head() string {
+	if len(l.values) > 0 {
+		if s, ok := l.values[0].(*Symbol); ok {
+			return s.name
+		}
+	}
+	return ""
+}
+
+func (l *List) Equal(other Object) bool {
+	return equalSequences(l, other)
+}
+
+func (l *List) Hash() uint64 {
+	return hashSequence(l)
+}
+
+func (l *List) Compare(other Object) (int, error) {
+	return compareSequences(l, other)
+}
+
+// Unquote is ,x or ,@xs inside of a quote. It's replaced by the value of Expr, when the quote is evaluated.
+type Unquote struct {
+	Expr   ast.Expression
+	Splice bool
+}
+
+func (u *Unquote) Boolean() bool {
+	return true
+}
+
+func (u *Unquote) Str() string {
+	if u.Splice {
+		return ",@..."
+	}
+	return ",..."
+}
+
+func (u *Unquote) Class() string {
+	return "Unquote"
+}
+
+// Code is quoted code without a representation as data, like an interpolated string.
+// It's inserted as is, when the quote is turned back into code.
+type Code struct {
+	Expr   ast.Expression
+	Source string
+}
+
+func (c *Code) Boolean() bool {
+	return true
+}
+
+func (c *Code) Str() string {
+	return c.Source
+}
+
+func (c *Code) Class() string {
+	return "Code"
+}
+
+// Macro is defined with (macro name (args) body...).
+// It's called with its arguments as quoted code, and returns the code to replace the call with.
+type Macro struct {
+	*Function
+}
+
+func (m *Macro) Str() string {
+	return "(macro [...] ...)"
+}
+
+func (m *Macro) Class() string {
+	return "Macro"
+}
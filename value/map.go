@@ -0,0 +1,190 @@
+package value
+
+import (
+	"strconv"
+)
+
+type mapEntry struct {
+	key   Object
+	value Object
+}
+
+// Map keeps its entries in insertion order,
+// so that printing and iterating a map is deterministic.
+// Keys are found using Hash and Equal, see protocol.go
+type Map struct {
+	entries []mapEntry
+	// hash of key -> positions in entries
+	index map[uint64][]int
+}
+
+func NewMap() *Map {
+	return &Map{index: make(map[uint64][]int)}
+}
+
+// find returns the position of key in entries, or -1.
+// The hash of the key is returned as well, so it doesn't need to be computed twice.
+func (m *Map) find(key Object) (int, uint64, error) {
+	h, err := Hash(key)
+	if err != nil {
+		return -1, 0, err
+	}
+
+	for _, i := range m.index[h] {
+		if Equal(m.entries[i].key, key) {
+			return i, h, nil
+		}
+	}
+
+	return -1, h, nil
+}
+
+func (m *Map) Boolean() bool {
+	return true
+}
+
+func (m *Map) Str() string {
+	s := "{"
+	for i, e := range m.entries {
+		if i > 0 {
+			s += " "
+		}
+		s += repr(e.key) + " " + repr(e.value)
+	}
+	return s + "}"
+}
+
+func (m *Map) Class() string {
+	return "Map"
+}
+
+func (m *Map) Len() int {
+	return len(m.entries)
+}
+
+// Get returns the value stored under key.
+// The boolean is false, if there is no such key.
+func (m *Map) Get(key Object) (Object, bool, error) {
+	i, _, err := m.find(key)
+	if err != nil || i < 0 {
+		return nil, false, err
+	}
+
+	return m.entries[i].value, true, nil
+}
+
+func (m *Map) Has(key Object) (bool, error) {
+	_, ok, err := m.Get(key)
+	return ok, err
+}
+
+// Set mutates the map in place.
+// Builtins should work on a Copy, maps are values in the language.
+func (m *Map) Set(key Object, value Object) error {
+	i, h, err := m.find(key)
+	if err != nil {
+		return err
+	}
+
+	if i >= 0 {
+		m.entries[i].value = value
+		return nil
+	}
+
+	m.index[h] = append(m.index[h], len(m.entries))
+	m.entries = append(m.entries, mapEntry{keyOf(key), value})
+	return nil
+}
+
+// Delete mutates the map in place, see Set.
+func (m *Map) Delete(key Object) error {
+	i, _, err := m.find(key)
+	if err != nil || i < 0 {
+		return err
+	}
+
+	m.entries = append(m.entries[:i:i], m.entries[i+1:]...)
+
+	// all entries behind the deleted one moved, rebuild the index
+	m.index = make(map[uint64][]int, len(m.entries))
+	for j, e := range m.entries {
+		h := hashOf(e.key)
+		m.index[h] = append(m.index[h], j)
+	}
+
+	return nil
+}
+
+func (m *Map) Copy() *Map {
+	c := &Map{
+		entries: make([]mapEntry, len(m.entries)),
+		index:   make(map[uint64][]int, len(m.index)),
+	}
+
+	copy(c.entries, m.entries)
+	for h, positions := range m.index {
+		c.index[h] = append([]int(nil), positions...)
+	}
+
+	return c
+}
+
+func (m *Map) Keys() []Object {
+	keys := make([]Object, 0, len(m.entries))
+	for _, e := range m.entries {
+		keys = append(keys, keyOf(e.key))
+	}
+	return keys
+}
+
+// keyOf returns a deep copy of key, if it contains an array.
+// push changes arrays in place, which must not change the hash of a key inside of a map.
+// Maps and class instances are copied, as they can hold arrays as well.
+func keyOf(key Object) Object {
+	switch key := key.(type) {
+	case *Array:
+		values := make([]Object, len(key.values))
+		for i, v := range key.values {
+			values[i] = keyOf(v)
+		}
+		return NewArrayOf(values)
+	case *IntArray:
+		return NewIntArray(append([]int64(nil), key.values...))
+	case *FloatArray:
+		return NewFloatArray(append([]float64(nil), key.values...))
+	case *ByteArray:
+		return NewByteArray(append([]byte(nil), key.values...))
+	case *Map:
+		m := key.Copy()
+		for i, e := range m.entries {
+			m.entries[i].value = keyOf(e.value)
+		}
+		return m
+	case *Class:
+		fields := make([]Object, len(key.fields))
+		for i, v := range key.fields {
+			fields[i] = keyOf(v)
+		}
+		return &Class{fields: fields, info: key.info}
+	}
+
+	return key
+}
+
+func (m *Map) Values() []Object {
+	values := make([]Object, 0, len(m.entries))
+	for _, e := range m.entries {
+		values = append(values, e.value)
+	}
+	return values
+}
+
+// repr is like Str, but strings are quoted,
+// so that they can be told apart from other values inside of collections.
+func repr(o Object) string {
+	if s, ok := o.(*StringClass); ok {
+		return strconv.Quote(s.value)
+	}
+
+	return o.Str()
+}
@@ -0,0 +1,59 @@
+package value
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Module is a file loaded with import. Names starting with _ are private to it.
+type Module struct {
+	path    string
+	members map[string]*Binding
+}
+
+// NewModule exports all public members
+func NewModule(path string, members map[string]*Binding) *Module {
+	return &Module{path: path, members: members}
+}
+
+func (m *Module) Boolean() bool {
+	return true
+}
+
+func (m *Module) Str() string {
+	return "(module " + m.path + ")"
+}
+
+func (m *Module) Class() string {
+	return "Module"
+}
+
+func (m *Module) Path() string {
+	return m.path
+}
+
+// Get returns the current value of a public member
+func (m *Module) Get(ident string) (Object, error) {
+	if strings.HasPrefix(ident, "_") {
+		return nil, errors.New(ident + " is private to module " + m.path)
+	}
+
+	b, ok := m.members[ident]
+	if !ok {
+		return nil, errors.New("module " + m.path + " has no member " + ident)
+	}
+	return b.Value, nil
+}
+
+// Exports returns the names of all public members, sorted
+func (m *Module) Exports() []string {
+	names := make([]string, 0, len(m.members))
+	for name := range m.members {
+		if !strings.HasPrefix(name, "_") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
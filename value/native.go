@@ -45,12 +45,6 @@ func (c *NativeFunction) Boolean() bool {
 
 	// functions to deal with io
 
-	read "filename"
-	readBytes "filename"
-
-	write "filename" str
-	writebytes "filename" []int
-
 	print
 	println
 	printbytes
@@ -0,0 +1,280 @@
+package value
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+)
+
+// Equaler is implemented by values that know how to compare themselves to others.
+// Values without it are only equal to themselves.
+type Equaler interface {
+	Equal(other Object) bool
+}
+
+// Hasher is implemented by values that can be used as map keys.
+// Values that are Equal must have the same Hash.
+type Hasher interface {
+	Hash() uint64
+}
+
+// Comparer is implemented by values that have an order.
+// Compare returns a negative number, 0 or a positive number,
+// if the value is smaller, equal or bigger than other.
+type Comparer interface {
+	Compare(other Object) (int, error)
+}
+
+func Equal(a Object, b Object) bool {
+	if e, ok := a.(Equaler); ok {
+		return e.Equal(b)
+	}
+
+	return a == b
+}
+
+func Hash(o Object) (uint64, error) {
+	if h, ok := o.(Hasher); ok {
+		return h.Hash(), nil
+	}
+
+	return 0, errors.New("value of type " + o.Class() + " is not hashable")
+}
+
+func Compare(a Object, b Object) (int, error) {
+	if c, ok := a.(Comparer); ok {
+		return c.Compare(b)
+	}
+
+	return 0, notComparable(a, b)
+}
+
+func notComparable(a Object, b Object) error {
+	return errors.New("can't compare " + a.Class() + " with " + b.Class())
+}
+
+// hashOf is used for elements of collections.
+// Unhashable elements only weaken the hash, equality is still checked using Equal.
+func hashOf(o Object) uint64 {
+	h, _ := Hash(o)
+	return h
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+func hashInt(i int64) uint64 {
+	// splitmix64 finalizer, to spread small integers
+	x := uint64(i)
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+func hashCombine(h uint64, x uint64) uint64 {
+	return (h ^ x) * 1099511628211
+}
+
+func compareInts(a int64, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloats(a float64, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Nil
+
+func (n *NilClass) Equal(other Object) bool {
+	_, ok := other.(*NilClass)
+	return ok
+}
+
+func (n *NilClass) Hash() uint64 {
+	return 0
+}
+
+func (n *NilClass) Compare(other Object) (int, error) {
+	if _, ok := other.(*NilClass); ok {
+		return 0, nil
+	}
+	return 0, notComparable(n, other)
+}
+
+// Bool
+
+func (b *BoolClass) Equal(other Object) bool {
+	o, ok := other.(*BoolClass)
+	return ok && o.value == b.value
+}
+
+func (b *BoolClass) Hash() uint64 {
+	if b.value {
+		return 1
+	}
+	return 2
+}
+
+// false < true
+func (b *BoolClass) Compare(other Object) (int, error) {
+	o, ok := other.(*BoolClass)
+	if !ok {
+		return 0, notComparable(b, other)
+	}
+
+	switch {
+	case b.value == o.value:
+		return 0, nil
+	case b.value:
+		return 1, nil
+	}
+	return -1, nil
+}
+
+// Numbers are compared by their numeric value, so (== 1 1.) holds
+
+func (i *IntClass) Equal(other Object) bool {
+	switch o := other.(type) {
+	case *IntClass:
+		return i.value == o.value
+	case *FloatClass:
+		return float64(i.value) == o.value
+	case *BigInt, *Rational, *Decimal:
+		return Equal(other, i)
+	}
+	return false
+}
+
+func (i *IntClass) Hash() uint64 {
+	return hashInt(i.value)
+}
+
+func (i *IntClass) Compare(other Object) (int, error) {
+	switch o := other.(type) {
+	case *IntClass:
+		return compareInts(i.value, o.value), nil
+	case *FloatClass:
+		return compareFloats(float64(i.value), o.value), nil
+	case *BigInt, *Rational, *Decimal:
+		c, err := Compare(other, i)
+		return -c, err
+	}
+	return 0, notComparable(i, other)
+}
+
+func (f *FloatClass) Equal(other Object) bool {
+	switch o := other.(type) {
+	case *FloatClass:
+		return f.value == o.value
+	case *IntClass:
+		return f.value == float64(o.value)
+	case *BigInt, *Rational, *Decimal:
+		return Equal(other, f)
+	}
+	return false
+}
+
+func (f *FloatClass) Hash() uint64 {
+	// integral floats need to hash like the equal Int
+	if f.value == math.Trunc(f.value) && f.value >= -0x1p63 && f.value < 0x1p63 {
+		return hashInt(int64(f.value))
+	}
+	return hashInt(int64(math.Float64bits(f.value)))
+}
+
+func (f *FloatClass) Compare(other Object) (int, error) {
+	switch o := other.(type) {
+	case *FloatClass:
+		return compareFloats(f.value, o.value), nil
+	case *IntClass:
+		return compareFloats(f.value, float64(o.value)), nil
+	case *BigInt, *Rational, *Decimal:
+		c, err := Compare(other, f)
+		return -c, err
+	}
+	return 0, notComparable(f, other)
+}
+
+// String
+
+func (s *StringClass) Equal(other Object) bool {
+	o, ok := other.(*StringClass)
+	return ok && o.value == s.value
+}
+
+func (s *StringClass) Hash() uint64 {
+	return hashString(s.value)
+}
+
+func (s *StringClass) Compare(other Object) (int, error) {
+	o, ok := other.(*StringClass)
+	if !ok {
+		return 0, notComparable(s, other)
+	}
+
+	switch {
+	case s.value < o.value:
+		return -1, nil
+	case s.value > o.value:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Array is compared element by element, see sequence.go
+
+func (a *Array) Equal(other Object) bool {
+	return equalSequences(a, other)
+}
+
+func (a *Array) Hash() uint64 {
+	return hashSequence(a)
+}
+
+func (a *Array) Compare(other Object) (int, error) {
+	return compareSequences(a, other)
+}
+
+// Map equality ignores the order of insertion
+
+func (m *Map) Equal(other Object) bool {
+	o, ok := other.(*Map)
+	if !ok || m.Len() != o.Len() {
+		return false
+	}
+
+	for _, e := range m.entries {
+		v, ok, err := o.Get(e.key)
+		if err != nil || !ok || !Equal(e.value, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Map) Hash() uint64 {
+	// entries are summed up, so that order does not matter
+	h := hashString(m.Class())
+	for _, e := range m.entries {
+		h += hashCombine(hashOf(e.key), hashOf(e.value))
+	}
+	return h
+}
@@ -0,0 +1,84 @@
+package value
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Range is the lazy sequence of Ints from, from+step, ... up to, but excluding, to.
+// Its elements are computed on access, so that (lazy-range 1000000000) doesn't allocate.
+type Range struct {
+	from, to, step int64
+	length         int
+}
+
+// NewRange expects a step other than 0. It fails, if the range has more elements than fit in an int.
+func NewRange(from, to, step int64) (*Range, error) {
+	if (step > 0 && from >= to) || (step < 0 && from <= to) {
+		return &Range{from, to, step, 0}, nil
+	}
+
+	// the distance between two int64 always fits in an uint64
+	distance, stride := uint64(to)-uint64(from), uint64(step)
+	if step < 0 {
+		distance, stride = uint64(from)-uint64(to), -uint64(step)
+	}
+
+	length := distance / stride
+	if distance%stride != 0 {
+		length++
+	}
+	if length > math.MaxInt {
+		return nil, errors.New(fmt.Sprint("range of ", length, " elements is too long"))
+	}
+
+	return &Range{from, to, step, int(length)}, nil
+}
+
+func (r *Range) Boolean() bool {
+	return true
+}
+
+func (r *Range) Str() string {
+	if r.step == 1 {
+		return fmt.Sprint("(lazy-range ", r.from, " ", r.to, ")")
+	}
+	return fmt.Sprint("(lazy-range ", r.from, " ", r.to, " ", r.step, ")")
+}
+
+func (r *Range) Class() string {
+	return "Range"
+}
+
+func (r *Range) Len() int {
+	return r.length
+}
+
+func (r *Range) At(i int) Object {
+	if i < 0 || i >= r.Len() {
+		panic(fmt.Sprint("index ", i, " out of range for range of length ", r.Len()))
+	}
+	return NewInt(r.from + int64(i)*r.step)
+}
+
+// Pick returns an IntArray, the picked elements usually aren't a range anymore
+func (r *Range) Pick(positions []int) Sequence {
+	values := make([]int64, 0, len(positions))
+	for _, i := range positions {
+		values = append(values, r.At(i).(*IntClass).value)
+	}
+	return NewIntArray(values)
+}
+
+func (r *Range) Equal(other Object) bool {
+	return equalSequences(r, other)
+}
+
+func (r *Range) Hash() uint64 {
+	return hashSequence(r)
+}
+
+func (r *Range) Compare(other Object) (int, error) {
+	return compareSequences(r, other)
+}
@@ -0,0 +1,95 @@
+package value
+
+import (
+	"math"
+	"math/big"
+)
+
+// Rational is an exact fraction like 1/3.
+// Rationals with a denominator of 1 are demoted to Ints, see NewRational.
+type Rational struct {
+	value *big.Rat
+}
+
+// NewRational returns an Int or BigInt, if value is integral. The value must not be changed afterwards.
+func NewRational(value *big.Rat) Object {
+	if value.IsInt() {
+		return NewBigInt(value.Num())
+	}
+
+	return &Rational{value}
+}
+
+// Rat returns the value of r. It must not be changed.
+func (r *Rational) Rat() *big.Rat {
+	return r.value
+}
+
+func (r *Rational) Boolean() bool {
+	return r.value.Sign() != 0
+}
+
+func (r *Rational) Str() string {
+	return r.value.String()
+}
+
+func (r *Rational) Class() string {
+	return "Rational"
+}
+
+func (r *Rational) Equal(other Object) bool {
+	c, err := r.Compare(other)
+	return err == nil && c == 0
+}
+
+func (r *Rational) Hash() uint64 {
+	return hashRat(r.value)
+}
+
+func (r *Rational) Compare(other Object) (int, error) {
+	return compareRat(r, r.value, other)
+}
+
+// ToRat converts all numbers except for NaN and infinite Floats
+func ToRat(o Object) (*big.Rat, bool) {
+	switch o := o.(type) {
+	case *IntClass:
+		return new(big.Rat).SetInt64(o.value), true
+	case *BigInt:
+		return new(big.Rat).SetInt(o.value), true
+	case *Rational:
+		return o.value, true
+	case *Decimal:
+		return o.Rat(), true
+	case *FloatClass:
+		if math.IsNaN(o.value) || math.IsInf(o.value, 0) {
+			return nil, false
+		}
+		return new(big.Rat).SetFloat64(o.value), true
+	}
+	return nil, false
+}
+
+// compareRat compares the exact value x of o with any other number
+func compareRat(o Object, x *big.Rat, other Object) (int, error) {
+	if f, ok := other.(*FloatClass); ok && math.IsInf(f.value, 0) {
+		return -int(math.Copysign(1, f.value)), nil
+	}
+
+	y, ok := ToRat(other)
+	if !ok {
+		return 0, notComparable(o, other)
+	}
+	return x.Cmp(y), nil
+}
+
+// hashRat hashes like the equal Int, BigInt or Float
+func hashRat(r *big.Rat) uint64 {
+	if r.IsInt() {
+		return hashOf(NewBigInt(r.Num()))
+	}
+	if f, exact := r.Float64(); exact {
+		return hashInt(int64(math.Float64bits(f)))
+	}
+	return hashString(r.String())
+}
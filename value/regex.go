@@ -0,0 +1,49 @@
+package value
+
+import (
+	"regexp"
+)
+
+type Regex struct {
+	re *regexp.Regexp
+}
+
+func NewRegex(pattern string) (*Regex, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Regex{re}, nil
+}
+
+// FromRegexp wraps an already compiled expression
+func FromRegexp(re *regexp.Regexp) *Regex {
+	return &Regex{re}
+}
+
+func (r *Regex) Regexp() *regexp.Regexp {
+	return r.re
+}
+
+func (r *Regex) Boolean() bool {
+	return true
+}
+
+func (r *Regex) Str() string {
+	return "#/" + r.re.String() + "/"
+}
+
+func (r *Regex) Class() string {
+	return "Regex"
+}
+
+// regular expressions are equal, if their patterns are
+func (r *Regex) Equal(other Object) bool {
+	o, ok := other.(*Regex)
+	return ok && o.re.String() == r.re.String()
+}
+
+func (r *Regex) Hash() uint64 {
+	return hashString(r.re.String())
+}
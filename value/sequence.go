@@ -0,0 +1,126 @@
+package value
+
+// Sequence is implemented by Array and the typed arrays (IntArray, FloatArray, ByteArray),
+// so that the array builtins work with all of them.
+type Sequence interface {
+	Object
+
+	Len() int
+
+	// At returns the element at index i. It panics if i is out of range, just like a go slice.
+	At(i int) Object
+
+	// Pick returns a new sequence of the same type, containing the elements at the given positions.
+	// slicing, filtering, sorting and masking are all expressed this way.
+	Pick(positions []int) Sequence
+}
+
+// Elements returns the elements of s as a fresh slice
+func Elements(s Sequence) []Object {
+	values := make([]Object, 0, s.Len())
+	for i := 0; i < s.Len(); i++ {
+		values = append(values, s.At(i))
+	}
+	return values
+}
+
+// Concat keeps the type of typed arrays, if all of them have the same type.
+// Otherwise the result is an Array.
+func Concat(seqs ...Sequence) Sequence {
+	if len(seqs) > 0 {
+		switch first := seqs[0].(type) {
+		case *IntArray:
+			values := append([]int64(nil), first.values...)
+			for _, s := range seqs[1:] {
+				other, ok := s.(*IntArray)
+				if !ok {
+					return concatObjects(seqs)
+				}
+				values = append(values, other.values...)
+			}
+			return NewIntArray(values)
+
+		case *FloatArray:
+			values := append([]float64(nil), first.values...)
+			for _, s := range seqs[1:] {
+				other, ok := s.(*FloatArray)
+				if !ok {
+					return concatObjects(seqs)
+				}
+				values = append(values, other.values...)
+			}
+			return NewFloatArray(values)
+
+		case *ByteArray:
+			values := append([]byte(nil), first.values...)
+			for _, s := range seqs[1:] {
+				other, ok := s.(*ByteArray)
+				if !ok {
+					return concatObjects(seqs)
+				}
+				values = append(values, other.values...)
+			}
+			return NewByteArray(values)
+		}
+	}
+
+	return concatObjects(seqs)
+}
+
+func concatObjects(seqs []Sequence) Sequence {
+	values := make([]Object, 0)
+	for _, s := range seqs {
+		values = append(values, Elements(s)...)
+	}
+	return NewArrayOf(values)
+}
+
+func (a *Array) Pick(positions []int) Sequence {
+	values := make([]Object, 0, len(positions))
+	for _, i := range positions {
+		values = append(values, a.values[i])
+	}
+	return NewArrayOf(values)
+}
+
+// sequences are equal, if they contain equal elements, regardless of their type.
+// (== (int-array 1 2) [1 2]) holds
+func equalSequences(a Sequence, other Object) bool {
+	b, ok := other.(Sequence)
+	if !ok || a.Len() != b.Len() {
+		return false
+	}
+
+	for i := 0; i < a.Len(); i++ {
+		if !Equal(a.At(i), b.At(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+func hashSequence(s Sequence) uint64 {
+	// all sequences share the seed, because equal sequences need equal hashes
+	h := hashString("Array")
+	for i := 0; i < s.Len(); i++ {
+		h = hashCombine(h, hashOf(s.At(i)))
+	}
+	return h
+}
+
+// sequences are ordered lexicographically
+func compareSequences(a Sequence, other Object) (int, error) {
+	b, ok := other.(Sequence)
+	if !ok {
+		return 0, notComparable(a, other)
+	}
+
+	for i := 0; i < a.Len() && i < b.Len(); i++ {
+		c, err := Compare(a.At(i), b.At(i))
+		if err != nil || c != 0 {
+			return c, err
+		}
+	}
+
+	return compareInts(int64(a.Len()), int64(b.Len())), nil
+}
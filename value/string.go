@@ -1,5 +1,12 @@
 package value
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
 type StringClass struct {
 	value string
 }
@@ -19,3 +26,41 @@ func (f *StringClass) Str() string {
 func (f *StringClass) Class() string {
 	return "String"
 }
+
+func (f *StringClass) Value() string {
+	return f.value
+}
+
+// Len counts runes, not bytes
+func (f *StringClass) Len() int {
+	return utf8.RuneCountInString(f.value)
+}
+
+// Substr returns the runes from index `from` up to, but excluding, `to`
+func (f *StringClass) Substr(from int, to int) (*StringClass, error) {
+	runes := []rune(f.value)
+	if from < 0 || to > len(runes) || from > to {
+		return nil, errors.New(fmt.Sprint("substring [", from, " ", to, "] out of range for string of length ", len(runes)))
+	}
+
+	return &StringClass{string(runes[from:to])}, nil
+}
+
+// IndexOf returns the index of the first rune of sub in f, or -1
+func (f *StringClass) IndexOf(sub string) int {
+	i := strings.Index(f.value, sub)
+	if i < 0 {
+		return -1
+	}
+
+	return utf8.RuneCountInString(f.value[:i])
+}
+
+// Chars splits the string into strings of one rune each
+func (f *StringClass) Chars() []Object {
+	chars := make([]Object, 0, len(f.value))
+	for _, c := range f.value {
+		chars = append(chars, NewString(string(c)))
+	}
+	return chars
+}
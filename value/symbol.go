@@ -0,0 +1,114 @@
+package value
+
+import "sync"
+
+// Symbol is a name in quoted code, e.g. x in '(+ x 1)
+// Symbols are interned, so that equal symbols are the same pointer.
+type Symbol struct {
+	name string
+}
+
+// Keyword is written as :name. Like symbols, keywords are interned.
+// Unlike symbols, they always stand for themselves, e.g. as map keys {:a 1}
+type Keyword struct {
+	name string
+}
+
+var (
+	internLock sync.Mutex
+	symbols    = make(map[string]*Symbol)
+	keywords   = make(map[string]*Keyword)
+)
+
+func NewSymbol(name string) *Symbol {
+	internLock.Lock()
+	defer internLock.Unlock()
+
+	s, ok := symbols[name]
+	if !ok {
+		s = &Symbol{name}
+		symbols[name] = s
+	}
+	return s
+}
+
+// NewKeyword expects the name without the colon
+func NewKeyword(name string) *Keyword {
+	internLock.Lock()
+	defer internLock.Unlock()
+
+	k, ok := keywords[name]
+	if !ok {
+		k = &Keyword{name}
+		keywords[name] = k
+	}
+	return k
+}
+
+func (s *Symbol) Name() string {
+	return s.name
+}
+
+func (s *Symbol) Boolean() bool {
+	return true
+}
+
+func (s *Symbol) Str() string {
+	return s.name
+}
+
+func (s *Symbol) Class() string {
+	return "Symbol"
+}
+
+// symbols are equal if they are the same pointer, which is the default for Equal
+
+func (s *Symbol) Hash() uint64 {
+	return hashCombine(hashString("Symbol"), hashString(s.name))
+}
+
+func (s *Symbol) Compare(other Object) (int, error) {
+	o, ok := other.(*Symbol)
+	if !ok {
+		return 0, notComparable(s, other)
+	}
+	return compareNames(s.name, o.name), nil
+}
+
+func (k *Keyword) Name() string {
+	return k.name
+}
+
+func (k *Keyword) Boolean() bool {
+	return true
+}
+
+func (k *Keyword) Str() string {
+	return ":" + k.name
+}
+
+func (k *Keyword) Class() string {
+	return "Keyword"
+}
+
+func (k *Keyword) Hash() uint64 {
+	return hashCombine(hashString("Keyword"), hashString(k.name))
+}
+
+func (k *Keyword) Compare(other Object) (int, error) {
+	o, ok := other.(*Keyword)
+	if !ok {
+		return 0, notComparable(k, other)
+	}
+	return compareNames(k.name, o.name), nil
+}
+
+func compareNames(a string, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,263 @@
+package value
+
+import (
+	"errors"
+	"strings"
+)
+
+// Typed arrays store numbers unboxed in a go slice.
+// They are a lot smaller than an Array of Ints or Floats and arithmetic on them is vectorized,
+// see vectorized in arith.go
+
+type IntArray struct {
+	values []int64
+}
+
+func NewIntArray(values []int64) *IntArray {
+	return &IntArray{values}
+}
+
+func (a *IntArray) Boolean() bool {
+	return true
+}
+
+func (a *IntArray) Str() string {
+	return typedStr("int-array", a)
+}
+
+func (a *IntArray) Class() string {
+	return "IntArray"
+}
+
+func (a *IntArray) Len() int {
+	return len(a.values)
+}
+
+func (a *IntArray) At(i int) Object {
+	return NewInt(a.values[i])
+}
+
+func (a *IntArray) Pick(positions []int) Sequence {
+	values := make([]int64, 0, len(positions))
+	for _, i := range positions {
+		values = append(values, a.values[i])
+	}
+	return NewIntArray(values)
+}
+
+// Push appends values in place. Only Ints can be pushed.
+func (a *IntArray) Push(values ...Object) error {
+	for _, v := range values {
+		i, ok := v.(*IntClass)
+		if !ok {
+			return errors.New("can't push " + v.Class() + " onto IntArray")
+		}
+		a.values = append(a.values, i.value)
+	}
+	return nil
+}
+
+// Ints exposes the backing slice, changes to it are visible in the array
+func (a *IntArray) Ints() []int64 {
+	return a.values
+}
+
+func (a *IntArray) Equal(other Object) bool {
+	return equalSequences(a, other)
+}
+
+func (a *IntArray) Hash() uint64 {
+	return hashSequence(a)
+}
+
+func (a *IntArray) Compare(other Object) (int, error) {
+	return compareSequences(a, other)
+}
+
+type FloatArray struct {
+	values []float64
+}
+
+func NewFloatArray(values []float64) *FloatArray {
+	return &FloatArray{values}
+}
+
+func (a *FloatArray) Boolean() bool {
+	return true
+}
+
+func (a *FloatArray) Str() string {
+	return typedStr("float-array", a)
+}
+
+func (a *FloatArray) Class() string {
+	return "FloatArray"
+}
+
+func (a *FloatArray) Len() int {
+	return len(a.values)
+}
+
+func (a *FloatArray) At(i int) Object {
+	return NewFloat(a.values[i])
+}
+
+func (a *FloatArray) Pick(positions []int) Sequence {
+	values := make([]float64, 0, len(positions))
+	for _, i := range positions {
+		values = append(values, a.values[i])
+	}
+	return NewFloatArray(values)
+}
+
+// Push appends values in place. Ints are converted to Floats.
+func (a *FloatArray) Push(values ...Object) error {
+	for _, v := range values {
+		f, ok := toFloat(v)
+		if !ok {
+			return errors.New("can't push " + v.Class() + " onto FloatArray")
+		}
+		a.values = append(a.values, f)
+	}
+	return nil
+}
+
+// Floats exposes the backing slice, changes to it are visible in the array
+func (a *FloatArray) Floats() []float64 {
+	return a.values
+}
+
+func (a *FloatArray) Equal(other Object) bool {
+	return equalSequences(a, other)
+}
+
+func (a *FloatArray) Hash() uint64 {
+	return hashSequence(a)
+}
+
+func (a *FloatArray) Compare(other Object) (int, error) {
+	return compareSequences(a, other)
+}
+
+// ByteArray holds Ints from 0 to 255
+type ByteArray struct {
+	values []byte
+}
+
+func NewByteArray(values []byte) *ByteArray {
+	return &ByteArray{values}
+}
+
+func (a *ByteArray) Boolean() bool {
+	return true
+}
+
+func (a *ByteArray) Str() string {
+	return typedStr("byte-array", a)
+}
+
+func (a *ByteArray) Class() string {
+	return "ByteArray"
+}
+
+func (a *ByteArray) Len() int {
+	return len(a.values)
+}
+
+func (a *ByteArray) At(i int) Object {
+	return NewInt(int64(a.values[i]))
+}
+
+func (a *ByteArray) Pick(positions []int) Sequence {
+	values := make([]byte, 0, len(positions))
+	for _, i := range positions {
+		values = append(values, a.values[i])
+	}
+	return NewByteArray(values)
+}
+
+// Push appends values in place. Only Ints from 0 to 255 can be pushed.
+func (a *ByteArray) Push(values ...Object) error {
+	for _, v := range values {
+		b, err := ToByte(v)
+		if err != nil {
+			return err
+		}
+		a.values = append(a.values, b)
+	}
+	return nil
+}
+
+// Bytes exposes the backing slice, changes to it are visible in the array
+func (a *ByteArray) Bytes() []byte {
+	return a.values
+}
+
+func (a *ByteArray) Equal(other Object) bool {
+	return equalSequences(a, other)
+}
+
+func (a *ByteArray) Hash() uint64 {
+	return hashSequence(a)
+}
+
+func (a *ByteArray) Compare(other Object) (int, error) {
+	return compareSequences(a, other)
+}
+
+func ToByte(o Object) (byte, error) {
+	i, ok := o.(*IntClass)
+	if !ok || i.value < 0 || i.value > 255 {
+		return 0, errors.New("expected an Int from 0 to 255, got " + o.Str())
+	}
+	return byte(i.value), nil
+}
+
+// e.g. (int-array 1 2 3), the same way the array is constructed
+func typedStr(constructor string, s Sequence) string {
+	var b strings.Builder
+	b.WriteString("(" + constructor)
+	for i := 0; i < s.Len(); i++ {
+		b.WriteString(" ")
+		b.WriteString(s.At(i).Str())
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+// ToIntArray converts the elements of s, which all need to be Ints
+func ToIntArray(s Sequence) (*IntArray, error) {
+	if a, ok := s.(*IntArray); ok {
+		return NewIntArray(append([]int64(nil), a.values...)), nil
+	}
+
+	a := NewIntArray(make([]int64, 0, s.Len()))
+	for i := 0; i < s.Len(); i++ {
+		if err := a.Push(s.At(i)); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// ToFloatArray converts the elements of s, which all need to be numbers
+func ToFloatArray(s Sequence) (*FloatArray, error) {
+	a := NewFloatArray(make([]float64, 0, s.Len()))
+	for i := 0; i < s.Len(); i++ {
+		if err := a.Push(s.At(i)); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// ToByteArray converts the elements of s, which all need to be Ints from 0 to 255
+func ToByteArray(s Sequence) (*ByteArray, error) {
+	a := NewByteArray(make([]byte, 0, s.Len()))
+	for i := 0; i < s.Len(); i++ {
+		if err := a.Push(s.At(i)); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}